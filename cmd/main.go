@@ -4,29 +4,94 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/spf13/pflag"
 	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
 
 	"github.com/stolostron/grafana-dashboard-loader/pkg/controller"
+	"github.com/stolostron/grafana-dashboard-loader/pkg/metrics"
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	// exitUsage is returned for invalid arguments or unreadable input
+	exitUsage = 2
 )
 
 func main() {
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "sync":
+			os.Exit(runSync(os.Args[2:]))
+		}
+	}
+
 	klogFlags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	klog.InitFlags(klogFlags)
 	flagset := pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
 	flagset.AddGoFlagSet(klogFlags)
+	flagset.BoolVar(&util.DryRun, "dry-run", false, "Log the changes to grafana instead of making them")
+	metricsAddr := flagset.String("metrics-bind-address", "", "Address to serve the metrics at, disabled if empty")
+	dashboardDir := flagset.String("dashboard-dir", "", "Also load the dashboard json files and configmap manifests of the directory")
+	watchKubernetes := flagset.Bool("kubernetes", true, "Load the configmaps of the POD_NAMESPACE namespace")
+	flagset.IntVar(&controller.Workers, "workers", controller.Workers, "Number of configmaps loaded into grafana in parallel")
+	qps, burst := addRateLimitFlags(flagset)
+	flagset.IntVar(&util.CircuitFailureThreshold, "circuit-failure-threshold", util.CircuitFailureThreshold, "Number of failed requests in a row stopping the requests to grafana")
+	flagset.DurationVar(&util.CircuitOpenDuration, "circuit-open-duration", util.CircuitOpenDuration, "Time the requests to grafana are stopped before its health is probed again")
+	flagset.StringVar(&controller.DefaultConflictPolicy, "conflict-policy", controller.DefaultConflictPolicy, "How dashboards changed in grafana are handled without the annotation: overwrite, loader-owned or refuse")
+	flagset.BoolVar(&controller.UseFinalizers, "finalizers", false, "Block the deletion of dashboard configmaps until their dashboards are deleted from grafana")
+	flagset.DurationVar(&controller.FinalizerTimeout, "finalizer-timeout", controller.FinalizerTimeout, "Time after which the finalizer is removed even if the dashboards cannot be deleted")
+	addRenderFlags(flagset)
+	flagset.Parse(os.Args[1:])
+	util.SetRateLimit(*qps, *burst)
+	if err := controller.CheckConflictPolicy(controller.DefaultConflictPolicy); err != nil {
+		klog.Fatal(err)
+	}
+	if *dashboardDir == "" && !*watchKubernetes {
+		klog.Fatal("either --dashboard-dir or --kubernetes is required")
+	}
+
+	if util.DryRun {
+		klog.Info("running in dry run mode, grafana will not be changed")
+	}
+	if *metricsAddr != "" {
+		metrics.ReadinessCheck = util.CheckCircuit
+		go metrics.Serve(*metricsAddr)
+	}
 
 	// use a channel to synchronize the finalization for a graceful shutdown
 	stop := make(chan struct{})
 	defer close(stop)
 
-	controller.RunGrafanaDashboardController(stop)
+	if *dashboardDir != "" {
+		go func() {
+			err := controller.RunDirectoryLoader(*dashboardDir, stop)
+			if err != nil {
+				klog.Fatal("Failed to load dashboard directory ", "error ", err)
+			}
+		}()
+	}
+	if *watchKubernetes {
+		go controller.RunGrafanaDashboardController(stop)
+	}
 
 	// use a channel to handle OS signals to terminate and gracefully shut
 	// down processing
@@ -36,3 +101,259 @@ func main() {
 	<-sigTerm
 
 }
+
+// addRenderFlags adds the flags changing how dashboards are rendered
+func addRenderFlags(flagset *pflag.FlagSet) {
+	flagset.StringVar(&controller.GnetMirror, "gnet-mirror", "", "Directory or URL of the mirror of grafana.com dashboards, laid out as GNETID/REVISION.json")
+	flagset.StringVar(&controller.DefaultDatasource, "default-datasource", "", "Datasource for the datasource inputs of shared dashboards")
+	flagset.StringSliceVar(&controller.TemplateEnv, "template-env", nil, "Environment variables available to dashboard templates as .Env")
+}
+
+// addRateLimitFlags adds the flags limiting the requests to grafana
+func addRateLimitFlags(flagset *pflag.FlagSet) (*float64, *int) {
+	qps := flagset.Float64("grafana-qps", 20, "Maximum requests per second to grafana, unlimited if not positive")
+	burst := flagset.Int("grafana-burst", 20, "Maximum burst of requests to grafana")
+	flagset.DurationVar(&util.RequestTimeout, "grafana-timeout", util.RequestTimeout, "Timeout of each attempt of a request to grafana, no timeout if not positive")
+	return qps, burst
+}
+
+// runValidate lints dashboard configmap manifests offline
+func runValidate(args []string) int {
+	flagset := pflag.NewFlagSet("validate", pflag.ContinueOnError)
+	output := flagset.StringP("output", "o", "text", "Output format, text or json")
+	addRenderFlags(flagset)
+	flagset.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: grafana-dashboard-loader validate [flags] FILE|DIR...")
+		flagset.PrintDefaults()
+	}
+	err := flagset.Parse(args)
+	if err != nil {
+		return exitUsage
+	}
+	if flagset.NArg() == 0 || (*output != "text" && *output != "json") {
+		flagset.Usage()
+		return exitUsage
+	}
+
+	report, err := controller.ValidateConfigmapFiles(flagset.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	if *output == "json" {
+		b, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		for _, issue := range report.Issues {
+			fmt.Printf("%v: %v %v: %v: %v\n", issue.File, issue.ConfigMap, issue.Key, issue.Severity, issue.Message)
+		}
+		fmt.Printf("%v configmaps, %v dashboards, %v errors, %v warnings\n",
+			report.ConfigMaps, report.Dashboards, report.Errors, report.Warnings)
+	}
+
+	if report.Errors > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// runDiff prints the changes the loader would make to grafana, with the exit code 1
+// when there are changes like diff
+func runDiff(args []string) int {
+	flagset := pflag.NewFlagSet("diff", pflag.ContinueOnError)
+	opts := controller.DiffOptions{}
+	flagset.StringVar(&opts.GrafanaURL, "grafana-url", "", "Grafana URL, the local grafana by default")
+	flagset.StringVarP(&opts.Namespace, "namespace", "n", "", "Load the configmaps of the namespace from the cluster instead of files")
+	flagset.StringVar(&opts.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	output := flagset.StringP("output", "o", "text", "Output format, text or json")
+	addRenderFlags(flagset)
+	flagset.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: grafana-dashboard-loader diff [flags] FILE|DIR...")
+		flagset.PrintDefaults()
+	}
+	err := flagset.Parse(args)
+	if err != nil {
+		return exitUsage
+	}
+	opts.Files = flagset.Args()
+	if (len(opts.Files) == 0) == (opts.Namespace == "") || (*output != "text" && *output != "json") {
+		flagset.Usage()
+		return exitUsage
+	}
+
+	report, err := controller.DiffConfigmaps(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	if *output == "json" {
+		b, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		for _, d := range report.Dashboards {
+			name := fmt.Sprintf("%v %v (%v)", d.ConfigMap, d.Key, d.UID)
+			switch {
+			case d.Error != "":
+				fmt.Printf("! %v %v: %v\n", d.ConfigMap, d.Key, d.Error)
+			case d.Action == controller.ChangeCreate:
+				fmt.Printf("+ %v create in folder %q\n", name, d.Folder)
+			case d.Action == controller.ChangeUpdate:
+				fmt.Printf("~ %v update\n", name)
+				if d.LiveFolder != d.Folder {
+					fmt.Printf("    move from folder %q to %q\n", d.LiveFolder, d.Folder)
+				}
+				for _, change := range d.Changes {
+					live, _ := json.Marshal(change.Live)
+					desired, _ := json.Marshal(change.Desired)
+					switch change.Op {
+					case "add":
+						fmt.Printf("    + %v: %s\n", change.Path, desired)
+					case "remove":
+						fmt.Printf("    - %v: %s\n", change.Path, live)
+					default:
+						fmt.Printf("    ~ %v: %s => %s\n", change.Path, live, desired)
+					}
+				}
+			default:
+				fmt.Printf("= %v unchanged\n", name)
+			}
+		}
+		for _, folder := range report.Folders {
+			fmt.Printf("%v folder %q\n", folder.Action, folder.Title)
+		}
+	}
+
+	if report.HasErrors() {
+		return exitUsage
+	}
+	if report.HasChanges() {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// runExport prints the grafana dashboards of the folders or tags as dashboard configmaps
+func runExport(args []string) int {
+	flagset := pflag.NewFlagSet("export", pflag.ContinueOnError)
+	opts := controller.ExportOptions{}
+	flagset.StringVar(&opts.GrafanaURL, "grafana-url", "", "Grafana URL, the local grafana by default")
+	flagset.StringArrayVar(&opts.Folders, "folder", nil, "Export the dashboards of the folder, General for the general folder")
+	flagset.StringArrayVar(&opts.Tags, "tag", nil, "Export the dashboards with the tag")
+	flagset.StringVarP(&opts.Namespace, "namespace", "n", "", "Namespace of the configmaps")
+	outputDir := flagset.String("output-dir", "", "Write one file per configmap into the directory instead of stdout")
+	flagset.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: grafana-dashboard-loader export [flags]")
+		flagset.PrintDefaults()
+	}
+	err := flagset.Parse(args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(opts.Folders) == 0 && len(opts.Tags) == 0 {
+		flagset.Usage()
+		return exitUsage
+	}
+
+	cms, err := controller.ExportDashboards(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	for i, cm := range cms {
+		b, err := yaml.Marshal(cm)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		if *outputDir == "" {
+			if i > 0 {
+				fmt.Println("---")
+			}
+			fmt.Print(string(b))
+			continue
+		}
+		err = ioutil.WriteFile(filepath.Join(*outputDir, cm.GetName()+".yaml"), b, 0644)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+	}
+	return exitOK
+}
+
+// runSync loads the dashboard configmaps into grafana once, with the exit code 1 when
+// any dashboard or prune fails
+func runSync(args []string) int {
+	flagset := pflag.NewFlagSet("sync", pflag.ContinueOnError)
+	opts := controller.SyncOptions{}
+	flagset.StringVar(&opts.GrafanaURL, "grafana-url", "", "Grafana URL, the local grafana by default")
+	flagset.StringVarP(&opts.Namespace, "namespace", "n", "", "Load the configmaps of the namespace from the cluster instead of files")
+	flagset.StringVar(&opts.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	flagset.BoolVar(&opts.Prune, "prune", false, "Delete the dashboards of the synced folders which no configmap defines")
+	flagset.BoolVar(&util.DryRun, "dry-run", false, "Log the changes to grafana instead of making them")
+	flagset.IntVar(&opts.Workers, "workers", controller.Workers, "Number of configmaps loaded into grafana in parallel")
+	qps, burst := addRateLimitFlags(flagset)
+	flagset.StringVar(&controller.DefaultConflictPolicy, "conflict-policy", controller.DefaultConflictPolicy, "How dashboards changed in grafana are handled without the annotation: overwrite, loader-owned or refuse")
+	output := flagset.StringP("output", "o", "text", "Output format, text or json")
+	addRenderFlags(flagset)
+	flagset.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: grafana-dashboard-loader sync [flags] FILE|DIR...")
+		flagset.PrintDefaults()
+	}
+	err := flagset.Parse(args)
+	if err != nil {
+		return exitUsage
+	}
+	util.SetRateLimit(*qps, *burst)
+	if err := controller.CheckConflictPolicy(controller.DefaultConflictPolicy); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	opts.Files = flagset.Args()
+	if (len(opts.Files) == 0) == (opts.Namespace == "") || (*output != "text" && *output != "json") {
+		flagset.Usage()
+		return exitUsage
+	}
+
+	report, err := controller.SyncConfigmaps(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	if *output == "json" {
+		b, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		dashboards := 0
+		for _, result := range report.ConfigMaps {
+			if result.Error != "" {
+				fmt.Printf("! %v: %v\n", result.ConfigMap, result.Error)
+				continue
+			}
+			dashboards += result.Dashboards
+			fmt.Printf("= %v %v dashboards in folder %q\n", result.ConfigMap, result.Dashboards, result.Folder)
+		}
+		for _, pruned := range report.Pruned {
+			name := fmt.Sprintf("%v %q", pruned.Kind, pruned.Folder)
+			if pruned.Kind == "dashboard" {
+				name = fmt.Sprintf("dashboard %q (%v) in folder %q", pruned.Title, pruned.UID, pruned.Folder)
+			}
+			if pruned.Error != "" {
+				fmt.Printf("! %v: %v\n", name, pruned.Error)
+				continue
+			}
+			fmt.Printf("- %v\n", name)
+		}
+		fmt.Printf("%v configmaps, %v dashboards synced, %v pruned, %v failures\n",
+			len(report.ConfigMaps), dashboards, len(report.Pruned), report.Failures)
+	}
+
+	if report.Failures > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
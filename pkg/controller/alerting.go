@@ -0,0 +1,420 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+const (
+	alertRuleLabel = "grafana-custom-alert-rule"
+	// defaultRuleGroupInterval is the evaluation interval of the rule groups without interval
+	defaultRuleGroupInterval = time.Minute
+)
+
+var invalidUIDChars = regexp.MustCompile("[^a-zA-Z0-9-_]+")
+
+// alertingProvisioning is the grafana alerting provisioning file format
+type alertingProvisioning struct {
+	Groups        []alertRuleGroup         `json:"groups"`
+	ContactPoints []contactPoint           `json:"contactPoints"`
+	Policies      []map[string]interface{} `json:"policies"`
+}
+
+type alertRuleGroup struct {
+	Name     string                   `json:"name"`
+	Folder   string                   `json:"folder"`
+	Interval string                   `json:"interval"`
+	Rules    []map[string]interface{} `json:"rules"`
+}
+
+type contactPoint struct {
+	Name      string                   `json:"name"`
+	Receivers []map[string]interface{} `json:"receivers"`
+}
+
+func isAlertRuleConfigmap(obj interface{}) bool {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return false
+	}
+
+	return strings.ToLower(cm.ObjectMeta.Labels[alertRuleLabel]) == "true"
+}
+
+// getAlertingProvisioning parses the yaml or json provisioning files of the configmap,
+// and sets the generated uids of the rules and contact points which do not have one
+func getAlertingProvisioning(obj interface{}) []alertingProvisioning {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return nil
+	}
+
+	provisionings := []alertingProvisioning{}
+	for key, value := range cm.Data {
+		provisioning := alertingProvisioning{}
+		err := yaml.Unmarshal([]byte(value), &provisioning)
+		if err != nil {
+			klog.Errorf("Failed to unmarshall data %v: %v", key, err)
+			continue
+		}
+
+		for _, group := range provisioning.Groups {
+			for _, rule := range group.Rules {
+				if uid, ok := rule["uid"].(string); !ok || uid == "" {
+					rule["uid"] = generateResourceUID(cm, group.Name+"-"+fmt.Sprint(rule["title"]))
+				}
+			}
+		}
+		for _, cp := range provisioning.ContactPoints {
+			for i, receiver := range cp.Receivers {
+				if uid, ok := receiver["uid"].(string); !ok || uid == "" {
+					receiver["uid"] = generateResourceUID(cm, cp.Name+"-"+fmt.Sprint(i))
+				}
+			}
+		}
+		provisionings = append(provisionings, provisioning)
+	}
+	return provisionings
+}
+
+// generateResourceUID generates a valid grafana uid for the named resource of the configmap
+func generateResourceUID(cm *corev1.ConfigMap, name string) string {
+	uid, _ := util.GenerateUID(cm.GetName()+"-"+invalidUIDChars.ReplaceAllString(name, "-"), cm.GetNamespace())
+	return uid
+}
+
+// getAlertingUIDs returns the uids of the alert rules and contact points of the configmap,
+// and whether it provisions the notification policies
+func getAlertingUIDs(obj interface{}) (map[string]bool, map[string]bool, bool) {
+	rules := map[string]bool{}
+	receivers := map[string]bool{}
+	hasPolicies := false
+	for _, provisioning := range getAlertingProvisioning(obj) {
+		for _, group := range provisioning.Groups {
+			for _, rule := range group.Rules {
+				rules[rule["uid"].(string)] = true
+			}
+		}
+		for _, cp := range provisioning.ContactPoints {
+			for _, receiver := range cp.Receivers {
+				receivers[receiver["uid"].(string)] = true
+			}
+		}
+		if len(provisioning.Policies) > 0 {
+			hasPolicies = true
+		}
+	}
+	return rules, receivers, hasPolicies
+}
+
+func getContactPointUIDs() map[string]bool {
+	grafanaURL := grafanaURI + "/api/v1/provisioning/contact-points"
+	body, _ := util.SetRequest("GET", grafanaURL, nil, retry)
+
+	contactPoints := []map[string]interface{}{}
+	err := json.Unmarshal(body, &contactPoints)
+	if err != nil {
+		klog.Error(unmarshallErrMsg, "error", err)
+		return nil
+	}
+
+	uids := map[string]bool{}
+	for _, cp := range contactPoints {
+		if uid, ok := cp["uid"].(string); ok {
+			uids[uid] = true
+		}
+	}
+	return uids
+}
+
+// upsertProvisioningResource creates the resource, or updates it at grafanaURL/uid when it exists
+func upsertProvisioningResource(grafanaURL, uid string, exists bool, data interface{}) bool {
+	b, err := json.Marshal(data)
+	if err != nil {
+		klog.Error("failed to marshal body", "error", err)
+		return false
+	}
+
+	method := "POST"
+	if exists {
+		method = "PUT"
+		grafanaURL = grafanaURL + "/" + uid
+	}
+	body, respStatusCode := util.SetRequest(method, grafanaURL, bytes.NewBuffer(b), retry)
+	if respStatusCode != http.StatusOK && respStatusCode != http.StatusCreated && respStatusCode != http.StatusAccepted {
+		klog.Infof("failed to create/update %v: %v %v", uid, respStatusCode, string(body))
+		return false
+	}
+	return true
+}
+
+func updateContactPoints(contactPoints []contactPoint) {
+	if len(contactPoints) == 0 {
+		return
+	}
+
+	existing := getContactPointUIDs()
+	grafanaURL := grafanaURI + "/api/v1/provisioning/contact-points"
+	for _, cp := range contactPoints {
+		for _, receiver := range cp.Receivers {
+			uid := receiver["uid"].(string)
+			data := map[string]interface{}{}
+			for k, v := range receiver {
+				data[k] = v
+			}
+			data["name"] = cp.Name
+			if upsertProvisioningResource(grafanaURL, uid, existing[uid], data) {
+				klog.Infof("Contact point %v created/updated", uid)
+			}
+		}
+	}
+}
+
+func updatePolicies(policies []map[string]interface{}) {
+	if len(policies) == 0 {
+		return
+	}
+
+	route := map[string]interface{}{}
+	for k, v := range policies[0] {
+		if k != "orgId" {
+			route[k] = v
+		}
+	}
+	b, err := json.Marshal(route)
+	if err != nil {
+		klog.Error("failed to marshal body", "error", err)
+		return
+	}
+
+	grafanaURL := grafanaURI + "/api/v1/provisioning/policies"
+	_, respStatusCode := util.SetRequest("PUT", grafanaURL, bytes.NewBuffer(b), retry)
+	if respStatusCode != http.StatusOK && respStatusCode != http.StatusAccepted {
+		klog.Infof("failed to update notification policies: %v", respStatusCode)
+		return
+	}
+	klog.Info("Notification policies updated")
+}
+
+func updateAlertRuleGroup(group alertRuleGroup, folderTitle string) {
+	// the folder is not deleted while the rules are written into it
+	folderDeletionMutex.RLock()
+	defer folderDeletionMutex.RUnlock()
+
+	if group.Folder != "" {
+		folderTitle = group.Folder
+	}
+	folderID := createCustomFolder(folderTitle)
+	if folderID == 0 {
+		klog.Error("Failed to get custom folder id")
+		return
+	}
+	folderUID := getCustomFolderUID(folderID)
+	if folderUID == "" {
+		klog.Error("Failed to get custom folder UID")
+		return
+	}
+
+	interval := defaultRuleGroupInterval
+	if group.Interval != "" {
+		var err error
+		interval, err = time.ParseDuration(group.Interval)
+		if err != nil {
+			klog.Errorf("invalid interval %v of rule group %v", group.Interval, group.Name)
+			return
+		}
+	}
+
+	rules := []interface{}{}
+	for _, rule := range group.Rules {
+		data := map[string]interface{}{}
+		for k, v := range rule {
+			data[k] = v
+		}
+		data["folderUID"] = folderUID
+		data["ruleGroup"] = group.Name
+		rules = append(rules, data)
+	}
+	// the rules of the group are created or updated at once
+	b, err := json.Marshal(map[string]interface{}{
+		"title":     group.Name,
+		"folderUid": folderUID,
+		"interval":  int64(interval.Seconds()),
+		"rules":     rules,
+	})
+	if err != nil {
+		klog.Error("failed to marshal body", "error", err)
+		return
+	}
+	grafanaURL := grafanaURI + "/api/v1/provisioning/folder/" + url.PathEscape(folderUID) + "/rule-groups/" + url.PathEscape(group.Name)
+	body, respStatusCode := util.SetRequest("PUT", grafanaURL, bytes.NewBuffer(b), retry)
+	if respStatusCode != http.StatusOK {
+		klog.Infof("failed to create/update rule group %v: %v %v", group.Name, respStatusCode, string(body))
+		return
+	}
+	klog.Infof("Alert rule group %v created/updated", group.Name)
+}
+
+// updateAlertRules is used to provision the alert rules, contact points and
+// notification policies via calling grafana api
+func updateAlertRules(old, new interface{}) {
+	folderTitle := getDashboardCustomFolderTitle(new)
+	if folderTitle == "" {
+		// alert rules cannot be stored in the general folder
+		folderTitle = defaultCustomFolder
+	}
+
+	provisionings := getAlertingProvisioning(new)
+	// contact points are referenced by the policies, which are referenced by the rules
+	for _, provisioning := range provisionings {
+		updateContactPoints(provisioning.ContactPoints)
+	}
+	for _, provisioning := range provisionings {
+		updatePolicies(provisioning.Policies)
+	}
+	for _, provisioning := range provisionings {
+		for _, group := range provisioning.Groups {
+			updateAlertRuleGroup(group, folderTitle)
+		}
+	}
+
+	// delete the resources removed from the configmap
+	oldRules, oldReceivers, oldPolicies := getAlertingUIDs(old)
+	newRules, newReceivers, newPolicies := getAlertingUIDs(new)
+	for uid := range oldRules {
+		if !newRules[uid] {
+			deleteAlertRule(uid)
+		}
+	}
+	if oldPolicies && !newPolicies {
+		resetPolicies()
+	}
+	for uid := range oldReceivers {
+		if !newReceivers[uid] {
+			deleteContactPoint(uid)
+		}
+	}
+	cleanupRuleFolders(old, new)
+}
+
+// deleteAlertRules is used to delete the alert rules, contact points and
+// notification policies of the configmap via calling grafana api
+func deleteAlertRules(obj interface{}) {
+	rules, receivers, policies := getAlertingUIDs(obj)
+	for uid := range rules {
+		deleteAlertRule(uid)
+	}
+	if policies {
+		resetPolicies()
+	}
+	for uid := range receivers {
+		deleteContactPoint(uid)
+	}
+	cleanupRuleFolders(obj, nil)
+}
+
+// cleanupRuleFolders deletes the folders of the old rule groups which became empty
+func cleanupRuleFolders(old, new interface{}) {
+	kept := map[string]bool{}
+	for _, title := range getRuleFolderTitles(new) {
+		kept[title] = true
+	}
+	for _, title := range getRuleFolderTitles(old) {
+		if kept[title] {
+			continue
+		}
+		deleteEmptyFolder(hasCustomFolder(title))
+	}
+}
+
+func getRuleFolderTitles(obj interface{}) []string {
+	if obj == nil {
+		return nil
+	}
+	folderTitle := getDashboardCustomFolderTitle(obj)
+	if folderTitle == "" {
+		folderTitle = defaultCustomFolder
+	}
+
+	titles := []string{}
+	for _, provisioning := range getAlertingProvisioning(obj) {
+		for _, group := range provisioning.Groups {
+			if group.Folder != "" {
+				titles = append(titles, group.Folder)
+			} else {
+				titles = append(titles, folderTitle)
+			}
+		}
+	}
+	return titles
+}
+
+func deleteProvisioningResource(grafanaURL string) bool {
+	_, respStatusCode := util.SetRequest("DELETE", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK && respStatusCode != http.StatusNoContent &&
+		respStatusCode != http.StatusAccepted && respStatusCode != http.StatusNotFound {
+		klog.Errorf("failed to delete %v with %v", grafanaURL, respStatusCode)
+		return false
+	}
+	return true
+}
+
+func deleteAlertRule(uid string) {
+	if deleteProvisioningResource(grafanaURI + "/api/v1/provisioning/alert-rules/" + uid) {
+		klog.Infof("Alert rule %v deleted", uid)
+	}
+}
+
+func deleteContactPoint(uid string) {
+	if deleteProvisioningResource(grafanaURI + "/api/v1/provisioning/contact-points/" + uid) {
+		klog.Infof("Contact point %v deleted", uid)
+	}
+}
+
+func resetPolicies() {
+	if deleteProvisioningResource(grafanaURI + "/api/v1/provisioning/policies") {
+		klog.Info("Notification policies reset")
+	}
+}
+
+// hasFolderAlertRules returns whether alert rules are in the folder, or whether it is unknown
+func hasFolderAlertRules(folderUID string) bool {
+	grafanaURL := grafanaURI + "/api/v1/provisioning/alert-rules"
+	body, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	if respStatusCode == http.StatusNotFound {
+		// grafana without the alerting provisioning api
+		return false
+	}
+	if respStatusCode != http.StatusOK {
+		klog.Errorf("failed to get alert rules with %v", respStatusCode)
+		return true
+	}
+
+	rules := []map[string]interface{}{}
+	err := json.Unmarshal(body, &rules)
+	if err != nil {
+		klog.Error(unmarshallErrMsg, "error", err)
+		return true
+	}
+	for _, rule := range rules {
+		if rule["folderUID"] == folderUID {
+			return true
+		}
+	}
+	return false
+}
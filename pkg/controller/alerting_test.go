@@ -0,0 +1,126 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var alertRuleConfigmap = &corev1.ConfigMap{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:        "alerts",
+		Namespace:   "ns",
+		Labels:      map[string]string{alertRuleLabel: "true"},
+		Annotations: map[string]string{customFolderKey: "Alerts"},
+	},
+	Data: map[string]string{
+		"rules.yaml": `
+apiVersion: 1
+groups:
+  - orgId: 1
+    name: cluster
+    interval: 60s
+    rules:
+      - uid: cpu-high
+        title: CPU high
+        condition: A
+      - title: Memory high
+        condition: A
+  - orgId: 1
+    name: network
+    folder: Network
+    rules:
+      - uid: net-errors
+        title: Network errors
+contactPoints:
+  - orgId: 1
+    name: oncall
+    receivers:
+      - type: email
+        settings:
+          addresses: oncall@example.com
+policies:
+  - orgId: 1
+    receiver: oncall
+`,
+		"invalid.yaml": "groups: {",
+	},
+}
+
+func TestIsAlertRuleConfigmap(t *testing.T) {
+	if !isAlertRuleConfigmap(alertRuleConfigmap) {
+		t.Errorf("the configmap should be an alert rule configmap")
+	}
+	if isAlertRuleConfigmap(nil) {
+		t.Errorf("nil should not be an alert rule configmap")
+	}
+}
+
+func TestGetAlertingUIDs(t *testing.T) {
+	rules, receivers, policies := getAlertingUIDs(alertRuleConfigmap)
+
+	expectedRules := map[string]bool{"cpu-high": true, "alerts-cluster-Memory-high-ns": true, "net-errors": true}
+	if !reflect.DeepEqual(rules, expectedRules) {
+		t.Errorf("the rules %v are not the expected %v", rules, expectedRules)
+	}
+	expectedReceivers := map[string]bool{"alerts-oncall-0-ns": true}
+	if !reflect.DeepEqual(receivers, expectedReceivers) {
+		t.Errorf("the receivers %v are not the expected %v", receivers, expectedReceivers)
+	}
+	if !policies {
+		t.Errorf("the configmap should provision notification policies")
+	}
+}
+
+func TestGetRuleFolderTitles(t *testing.T) {
+	titles := getRuleFolderTitles(alertRuleConfigmap)
+	if !reflect.DeepEqual(titles, []string{"Alerts", "Network"}) {
+		t.Errorf("the folders %v are not the expected [Alerts Network]", titles)
+	}
+
+	if getRuleFolderTitles(nil) != nil {
+		t.Errorf("there should be no folders without configmap")
+	}
+}
+
+func TestUpdateAlertRuleGroup(t *testing.T) {
+	requests := []string{}
+	groups := []map[string]interface{}{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/folders", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[{"id": 3, "uid": "alerts", "title": "Alerts"}]`))
+	})
+	mux.HandleFunc("/api/v1/provisioning/", func(w http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.Method+" "+req.URL.EscapedPath())
+		b, _ := ioutil.ReadAll(req.Body)
+		group := map[string]interface{}{}
+		json.Unmarshal(b, &group)
+		groups = append(groups, group)
+		w.Write([]byte("{}"))
+	})
+	startGrafanaServer(t, mux)
+
+	group := alertRuleGroup{
+		Name:  "cluster/cpu usage",
+		Rules: []map[string]interface{}{{"uid": "cpu-high"}, {"uid": "cpu-low"}},
+	}
+	updateAlertRuleGroup(group, "Alerts")
+
+	// the group is written at once with the escaped name
+	expected := []string{"PUT /api/v1/provisioning/folder/alerts/rule-groups/cluster%2Fcpu%20usage"}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Fatalf("the requests %v are not the expected %v", requests, expected)
+	}
+	rules, _ := groups[0]["rules"].([]interface{})
+	if groups[0]["interval"] != 60.0 || len(rules) != 2 {
+		t.Errorf("the rule group %v is not the expected", groups[0])
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+const (
+	annotationLabel = "grafana-custom-annotation"
+	// annotationOwnerTagPrefix prefixes the tag set on the annotations of a configmap
+	// so that they can be found and cleaned up
+	annotationOwnerTagPrefix = "grafana-dashboard-loader:"
+)
+
+// annotationSet is a set of static grafana annotations, e.g. maintenance windows
+type annotationSet struct {
+	Annotations []grafanaAnnotation `json:"annotations"`
+}
+
+// grafanaAnnotation is an annotation whose time and timeEnd are RFC3339 or epoch milliseconds
+type grafanaAnnotation struct {
+	DashboardUID string      `json:"dashboardUID,omitempty"`
+	PanelID      int64       `json:"panelId,omitempty"`
+	Time         interface{} `json:"time"`
+	TimeEnd      interface{} `json:"timeEnd,omitempty"`
+	Tags         []string    `json:"tags,omitempty"`
+	Text         string      `json:"text"`
+}
+
+func isAnnotationConfigmap(obj interface{}) bool {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return false
+	}
+
+	return strings.ToLower(cm.ObjectMeta.Labels[annotationLabel]) == "true"
+}
+
+// getAnnotationOwnerTag returns the tag identifying the annotations of the configmap
+func getAnnotationOwnerTag(cm *corev1.ConfigMap) string {
+	return annotationOwnerTagPrefix + cm.GetNamespace() + "/" + cm.GetName()
+}
+
+// toEpochMillis converts a RFC3339 time or epoch milliseconds to epoch milliseconds
+func toEpochMillis(t interface{}) (int64, error) {
+	switch v := t.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return int64(v), nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return 0, err
+		}
+		return parsed.UnixNano() / int64(time.Millisecond), nil
+	}
+	return 0, fmt.Errorf("invalid time %v", t)
+}
+
+// getAnnotations parses the yaml or json annotation sets of the configmap into annotations
+// ready to post, tagged with the configmap's owner tag
+func getAnnotations(cm *corev1.ConfigMap) []map[string]interface{} {
+	ownerTag := getAnnotationOwnerTag(cm)
+	annotations := []map[string]interface{}{}
+	for key, value := range cm.Data {
+		set := annotationSet{}
+		err := yaml.Unmarshal([]byte(value), &set)
+		if err != nil {
+			klog.Errorf("Failed to unmarshall data %v: %v", key, err)
+			continue
+		}
+
+		for _, a := range set.Annotations {
+			start, err := toEpochMillis(a.Time)
+			if err != nil || start == 0 {
+				klog.Errorf("invalid time of annotation %v in %v", a.Text, key)
+				continue
+			}
+			end, err := toEpochMillis(a.TimeEnd)
+			if err != nil {
+				klog.Errorf("invalid timeEnd of annotation %v in %v", a.Text, key)
+				continue
+			}
+
+			annotation := map[string]interface{}{
+				"time": start,
+				"text": a.Text,
+				"tags": append(append([]string{}, a.Tags...), ownerTag),
+			}
+			if end != 0 {
+				annotation["timeEnd"] = end
+			}
+			if a.DashboardUID != "" {
+				annotation["dashboardUID"] = a.DashboardUID
+			}
+			if a.PanelID != 0 {
+				annotation["panelId"] = a.PanelID
+			}
+			annotations = append(annotations, annotation)
+		}
+	}
+	return annotations
+}
+
+// getAnnotationKey returns the fields of the annotation compared to find changed annotations,
+// point annotations are returned by grafana with the timeEnd of their time
+func getAnnotationKey(annotation map[string]interface{}) string {
+	start := toInt64(annotation["time"])
+	end := toInt64(annotation["timeEnd"])
+	if end == 0 {
+		end = start
+	}
+	tags := []string{}
+	switch t := annotation["tags"].(type) {
+	case []string:
+		tags = append(tags, t...)
+	case []interface{}:
+		for _, tag := range t {
+			tags = append(tags, fmt.Sprint(tag))
+		}
+	}
+	sort.Strings(tags)
+	dashboardUID, _ := annotation["dashboardUID"].(string)
+	b, _ := json.Marshal([]interface{}{start, end, annotation["text"], tags, dashboardUID, toInt64(annotation["panelId"])})
+	return string(b)
+}
+
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case float64:
+		return int64(n)
+	case int64:
+		return n
+	}
+	return 0
+}
+
+// isAnnotationSetUnchanged returns whether the existing annotations are the desired ones
+func isAnnotationSetUnchanged(existing, desired []map[string]interface{}) bool {
+	if len(existing) != len(desired) {
+		return false
+	}
+	keys := map[string]int{}
+	for _, annotation := range existing {
+		keys[getAnnotationKey(annotation)]++
+	}
+	for _, annotation := range desired {
+		key := getAnnotationKey(annotation)
+		if keys[key] == 0 {
+			return false
+		}
+		keys[key]--
+	}
+	return true
+}
+
+// updateAnnotations is used to replace the annotations of the configmap via calling grafana api,
+// they are kept when unchanged so that their ids stay the same
+func updateAnnotations(obj interface{}) {
+	cm := obj.(*corev1.ConfigMap)
+	existing, ok := getOwnedAnnotations(cm)
+	if !ok {
+		return
+	}
+	annotations := getAnnotations(cm)
+	if isAnnotationSetUnchanged(existing, annotations) {
+		klog.V(2).Infof("annotations of %v are unchanged", cm.GetName())
+		return
+	}
+	if !deleteAnnotationList(existing) {
+		return
+	}
+
+	grafanaURL := grafanaURI + "/api/annotations"
+	for _, annotation := range annotations {
+		b, err := json.Marshal(annotation)
+		if err != nil {
+			klog.Error("failed to marshal body", "error", err)
+			continue
+		}
+		_, respStatusCode := util.SetRequest("POST", grafanaURL, bytes.NewBuffer(b), retry)
+		if respStatusCode != http.StatusOK {
+			klog.Infof("failed to create annotation %v: %v", annotation["text"], respStatusCode)
+			continue
+		}
+	}
+	klog.Infof("Annotations of %v created", cm.GetName())
+}
+
+// deleteAnnotations is used to delete the annotations owned by the configmap via calling grafana api
+func deleteAnnotations(obj interface{}) bool {
+	annotations, ok := getOwnedAnnotations(obj.(*corev1.ConfigMap))
+	if !ok {
+		return false
+	}
+	return deleteAnnotationList(annotations)
+}
+
+// getOwnedAnnotations returns the annotations in grafana tagged with the configmap's owner tag
+func getOwnedAnnotations(cm *corev1.ConfigMap) ([]map[string]interface{}, bool) {
+	grafanaURL := grafanaURI + "/api/annotations?type=annotation&limit=5000&tags=" +
+		url.QueryEscape(getAnnotationOwnerTag(cm))
+	body, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK {
+		klog.Errorf("failed to get annotations of %v with %v", cm.GetName(), respStatusCode)
+		return nil, false
+	}
+
+	annotations := []map[string]interface{}{}
+	err := json.Unmarshal(body, &annotations)
+	if err != nil {
+		klog.Error(unmarshallErrMsg, "error", err)
+		return nil, false
+	}
+	return annotations, true
+}
+
+func deleteAnnotationList(annotations []map[string]interface{}) bool {
+	deleted := true
+	for _, annotation := range annotations {
+		id, _ := annotation["id"].(float64)
+		grafanaURL := grafanaURI + "/api/annotations/" + fmt.Sprintf("%.0f", id)
+		_, respStatusCode := util.SetRequest("DELETE", grafanaURL, nil, retry)
+		if respStatusCode != http.StatusOK && respStatusCode != http.StatusNotFound {
+			klog.Errorf("failed to delete annotation %v with %v", annotation["id"], respStatusCode)
+			deleted = false
+		}
+	}
+	return deleted
+}
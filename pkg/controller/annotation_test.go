@@ -0,0 +1,106 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var annotationConfigmap = &corev1.ConfigMap{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "maintenance",
+		Namespace: "ns",
+		Labels:    map[string]string{annotationLabel: "true"},
+	},
+	Data: map[string]string{
+		"windows.yaml": `
+annotations:
+  - time: "2026-10-20T00:00:00Z"
+    timeEnd: "2026-10-20T02:00:00Z"
+    text: Upgrade
+    tags: [maintenance]
+  - dashboardUID: k8s-networking
+    time: 1792454400000
+    text: Network change
+  - time: tomorrow
+    text: Invalid
+`,
+	},
+}
+
+func TestGetAnnotations(t *testing.T) {
+	if !isAnnotationConfigmap(annotationConfigmap) {
+		t.Errorf("the configmap should be an annotation configmap")
+	}
+
+	annotations := getAnnotations(annotationConfigmap)
+	if len(annotations) != 2 {
+		t.Fatalf("the annotations %v are not the expected 2", annotations)
+	}
+
+	expected := map[string]interface{}{
+		"time":    int64(1792454400000),
+		"timeEnd": int64(1792461600000),
+		"text":    "Upgrade",
+		"tags":    []string{"maintenance", "grafana-dashboard-loader:ns/maintenance"},
+	}
+	if !reflect.DeepEqual(annotations[0], expected) {
+		t.Errorf("the annotation %v is not the expected %v", annotations[0], expected)
+	}
+	if annotations[1]["dashboardUID"] != "k8s-networking" {
+		t.Errorf("the annotation %v should be on dashboard k8s-networking", annotations[1])
+	}
+}
+
+func TestDeleteAnnotations(t *testing.T) {
+	startFakeServer(t)
+
+	if !deleteAnnotations(annotationConfigmap) {
+		t.Errorf("the annotations should be deleted")
+	}
+}
+
+func TestUpdateAnnotations(t *testing.T) {
+	existing := ""
+	requests := []string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/annotations", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			w.Write([]byte(existing))
+			return
+		}
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		w.Write([]byte("{}"))
+	})
+	mux.HandleFunc("/api/annotations/", func(w http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		w.Write([]byte("{}"))
+	})
+	startGrafanaServer(t, mux)
+
+	// the annotations as returned by grafana, in another order
+	existing = `[
+		{"id": 2, "dashboardUID": "k8s-networking", "time": 1792454400000, "timeEnd": 1792454400000,
+			"text": "Network change", "tags": ["grafana-dashboard-loader:ns/maintenance"]},
+		{"id": 1, "time": 1792454400000, "timeEnd": 1792461600000, "text": "Upgrade",
+			"tags": ["grafana-dashboard-loader:ns/maintenance", "maintenance"]}
+	]`
+	updateAnnotations(annotationConfigmap)
+	if len(requests) != 0 {
+		t.Errorf("the unchanged annotations should be kept, sent %v", requests)
+	}
+
+	existing = `[{"id": 1, "time": 1792454400000, "timeEnd": 1792461600000, "text": "Old",
+		"tags": ["grafana-dashboard-loader:ns/maintenance"]}]`
+	updateAnnotations(annotationConfigmap)
+	expected := []string{"DELETE /api/annotations/1", "POST /api/annotations", "POST /api/annotations"}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("the requests %v are not the expected %v", requests, expected)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+// configmapClient updates the annotations recording the state of the loader on configmaps,
+// so that it survives restarts, nil without kubernetes
+var configmapClient corev1client.CoreV1Interface
+
+// updateConfigmapAnnotation replaces the annotation of the latest version of the configmap with
+// the value returned by update, an empty value removes it, the configmaps of files are not
+// changed, nor are any configmaps in dry run mode
+func updateConfigmapAnnotation(cm *corev1.ConfigMap, key string, update func(value string) string) error {
+	if configmapClient == nil || util.DryRun || cm.GetUID() == "" {
+		return nil
+	}
+
+	latest, err := configmapClient.ConfigMaps(cm.Namespace).Get(context.TODO(), cm.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get configmap %v to annotate it: %v", getConfigmapName(cm), err)
+	}
+	value, ok := latest.Annotations[key]
+	updated := update(value)
+	if updated == value && (ok || updated == "") {
+		return nil
+	}
+	if updated == "" {
+		delete(latest.Annotations, key)
+	} else {
+		if latest.Annotations == nil {
+			latest.Annotations = map[string]string{}
+		}
+		latest.Annotations[key] = updated
+	}
+	_, err = configmapClient.ConfigMaps(cm.Namespace).Update(context.TODO(), latest, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to annotate configmap %v: %v", getConfigmapName(cm), err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// configmapFile is a configmap read from a yaml or json manifest
+type configmapFile struct {
+	path string
+	cm   *corev1.ConfigMap
+}
+
+// configmapLookup returns the configmap with the name in the namespace, or nil
+type configmapLookup func(namespace, name string) *corev1.ConfigMap
+
+var (
+	// configmapLookups find the configmaps referred to by dashboards, e.g. jsonnet libraries,
+	// in the sources of configmaps
+	configmapLookups []configmapLookup
+	lookupMutex      sync.RWMutex
+)
+
+func addConfigmapLookup(lookup configmapLookup) {
+	lookupMutex.Lock()
+	defer lookupMutex.Unlock()
+	configmapLookups = append(configmapLookups, lookup)
+}
+
+// addClientConfigmapLookup finds the configmaps in the cluster
+func addClientConfigmapLookup(coreClient corev1client.CoreV1Interface) {
+	addConfigmapLookup(func(namespace, name string) *corev1.ConfigMap {
+		cm, err := coreClient.ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil
+		}
+		return cm
+	})
+}
+
+// addFileConfigmapLookup finds the configmaps in the manifest files
+func addFileConfigmapLookup(files []configmapFile) {
+	addConfigmapLookup(func(namespace, name string) *corev1.ConfigMap {
+		for _, file := range files {
+			if file.cm.GetNamespace() == namespace && file.cm.GetName() == name {
+				return file.cm
+			}
+		}
+		return nil
+	})
+}
+
+// lookupConfigmap returns the configmap with the name in the namespace from the first
+// source which has it
+func lookupConfigmap(namespace, name string) (*corev1.ConfigMap, error) {
+	lookupMutex.RLock()
+	defer lookupMutex.RUnlock()
+	for _, lookup := range configmapLookups {
+		if cm := lookup(namespace, name); cm != nil {
+			return cm, nil
+		}
+	}
+	return nil, fmt.Errorf("configmap %v/%v not found", namespace, name)
+}
+
+// getConfigmapName returns the namespaced name of the configmap
+func getConfigmapName(cm *corev1.ConfigMap) string {
+	return cm.GetNamespace() + "/" + cm.GetName()
+}
+
+func isManifestFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml" || ext == ".json"
+}
+
+// loadConfigmaps reads the configmaps from the manifest files, or lists the configmaps
+// of the namespace from the cluster when a namespace is given
+func loadConfigmaps(paths []string, namespace, kubeconfig string) ([]configmapFile, error) {
+	if namespace == "" {
+		files, err := readConfigmapFiles(paths)
+		if err != nil {
+			return nil, err
+		}
+		addFileConfigmapLookup(files)
+		return files, nil
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules,
+		&clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	cms, err := kubeClient.CoreV1().ConfigMaps(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	addClientConfigmapLookup(kubeClient.CoreV1())
+
+	files := []configmapFile{}
+	for i := range cms.Items {
+		files = append(files, configmapFile{path: "configmap/" + cms.Items[i].GetName(), cm: &cms.Items[i]})
+	}
+	return files, nil
+}
+
+// readConfigmapFiles reads the configmaps from the manifest files, and from the
+// manifest files in the directories, skipping the other kinds of objects
+func readConfigmapFiles(paths []string) ([]configmapFile, error) {
+	files := []configmapFile{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			cms, err := readConfigmapOrDashboardFile(filepath.Dir(path), path)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, cms...)
+			continue
+		}
+
+		err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !isManifestFile(p) {
+				return nil
+			}
+			cms, err := readConfigmapOrDashboardFile(path, p)
+			if err != nil {
+				return err
+			}
+			files = append(files, cms...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// readConfigmapOrDashboardFile reads the configmaps of the manifest file, a dashboard json
+// file is wrapped into a dashboard configmap as in the dashboards directory of the controller
+func readConfigmapOrDashboardFile(dir, path string) ([]configmapFile, error) {
+	files, err := readConfigmapFile(path)
+	if strings.ToLower(filepath.Ext(path)) != ".json" || (err == nil && len(files) > 0) {
+		return files, err
+	}
+
+	rel, _ := filepath.Rel(dir, path)
+	cm, err := readDashboardFile(path, rel, newDirectorySource(dir, nil).getFolder(path))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return []configmapFile{{path: path, cm: cm}}, nil
+}
+
+func readConfigmapFile(path string) ([]configmapFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []configmapFile{}
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		doc := json.RawMessage{}
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		if len(doc) == 0 || string(doc) == "null" {
+			continue
+		}
+
+		object := struct {
+			Kind  string            `json:"kind"`
+			Items []json.RawMessage `json:"items"`
+		}{}
+		err = json.Unmarshal(doc, &object)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+
+		items := []json.RawMessage{doc}
+		if object.Kind == "List" || object.Kind == "ConfigMapList" {
+			items = object.Items
+		} else if object.Kind != "ConfigMap" {
+			continue
+		}
+		for _, item := range items {
+			cm := &corev1.ConfigMap{}
+			err = json.Unmarshal(item, cm)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", path, err)
+			}
+			if cm.Kind != "" && cm.Kind != "ConfigMap" {
+				continue
+			}
+			files = append(files, configmapFile{path: path, cm: cm})
+		}
+	}
+	return files, nil
+}
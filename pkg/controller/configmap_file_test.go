@@ -0,0 +1,84 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		t.Fatalf("fail to create directory with %v", err)
+	}
+	err = ioutil.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatalf("fail to write file with %v", err)
+	}
+	return path
+}
+
+func TestReadConfigmapFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configmaps")
+	if err != nil {
+		t.Fatalf("fail to create directory with %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestFile(t, dir, "multi.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: ignored
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: second
+`)
+	writeTestFile(t, dir, "nested/third.json", `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "third"}}`)
+	writeTestFile(t, dir, "team/dashboard.json", `{"title": "Dashboard"}`)
+	writeTestFile(t, dir, "README.md", "not a manifest")
+
+	files, err := readConfigmapFiles([]string{dir, "../../examples/k8s-dashboard.yaml"})
+	if err != nil {
+		t.Fatalf("fail to read configmaps with %v", err)
+	}
+
+	names := []string{}
+	for _, file := range files {
+		names = append(names, file.cm.GetName())
+	}
+	expected := []string{"first", "second", "third", "team-dashboard-json", "networking-cluster"}
+	if len(names) != len(expected) {
+		t.Fatalf("the configmaps %v are not the expected %v", names, expected)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("the configmaps %v are not the expected %v", names, expected)
+		}
+	}
+
+	// the dashboard json file is wrapped into a configmap in the folder of its directory
+	if cm := files[3].cm; !isDesiredDashboardConfigmap(cm) || getDashboardCustomFolderTitle(cm) != "team" {
+		t.Errorf("the dashboard configmap %+v is not the expected", cm)
+	}
+
+	_, err = readConfigmapFiles([]string{filepath.Join(dir, "missing.yaml")})
+	if err == nil {
+		t.Errorf("reading a missing file should fail")
+	}
+}
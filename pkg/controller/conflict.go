@@ -0,0 +1,158 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+const (
+	// conflictPolicyKey is the annotation choosing how dashboards changed in grafana since
+	// they were loaded are handled
+	conflictPolicyKey = "observability.open-cluster-management.io/dashboard-conflict-policy"
+
+	// conflictOverwrite always overwrites the dashboard in grafana
+	conflictOverwrite = "overwrite"
+	// conflictLoaderOwned overwrites the dashboard in grafana only if it was last saved by the loader
+	conflictLoaderOwned = "loader-owned"
+	// conflictRefuse never overwrites the dashboard in grafana and reports the conflict
+	conflictRefuse = "refuse"
+
+	// savedVersionsKey records the versions of the dashboards last saved by the loader by uid
+	// as a json object on the configmaps with the refuse policy, so that they are known after
+	// restarts of the loader
+	savedVersionsKey = "observability.open-cluster-management.io/saved-versions"
+)
+
+var (
+	// DefaultConflictPolicy is the conflict policy of configmaps without the annotation
+	DefaultConflictPolicy = conflictLoaderOwned
+)
+
+func isValidConflictPolicy(policy string) bool {
+	return policy == conflictOverwrite || policy == conflictLoaderOwned || policy == conflictRefuse
+}
+
+// CheckConflictPolicy returns an error when the conflict policy is unknown
+func CheckConflictPolicy(policy string) error {
+	if !isValidConflictPolicy(policy) {
+		return fmt.Errorf("invalid conflict policy %v, one of %v, %v or %v is expected", policy, conflictOverwrite, conflictLoaderOwned, conflictRefuse)
+	}
+	return nil
+}
+
+// getConflictPolicy returns the conflict policy of the configmap
+func getConflictPolicy(cm *corev1.ConfigMap) string {
+	policy, ok := cm.Annotations[conflictPolicyKey]
+	if !ok {
+		return DefaultConflictPolicy
+	}
+	if !isValidConflictPolicy(policy) {
+		klog.Warningf("invalid conflict policy %v of %v, use %v", policy, getConfigmapName(cm), DefaultConflictPolicy)
+		return DefaultConflictPolicy
+	}
+	return policy
+}
+
+// resolveVersionConflict handles the version mismatch of the dashboard posted with the data,
+// it is posted again on top of the live version when the conflict policy allows it, so that
+// a dashboard saved meanwhile is not overwritten
+func resolveVersionConflict(cm *corev1.ConfigMap, data map[string]interface{}) ([]byte, int, error) {
+	dashboard := data["dashboard"].(map[string]interface{})
+	uid := dashboard["uid"].(string)
+	live, meta, found := getLiveDashboardWithMeta(uid)
+	if !found {
+		return nil, 0, fmt.Errorf("failed to get dashboard %v to resolve the version conflict", uid)
+	}
+
+	updatedBy, _ := meta["updatedBy"].(string)
+	switch getConflictPolicy(cm) {
+	case conflictRefuse:
+		// the version of the configmap stays behind the versions saved by the loader, so any
+		// live version other than the one the loader saved last is refused, the dashboards
+		// saved before the versions were recorded are handled as loader-owned
+		liveVersion, _ := live["version"].(float64)
+		saved, found := getSavedVersion(cm, uid)
+		if updatedBy != util.GetGrafanaUser() || (found && liveVersion != saved) {
+			return nil, 0, fmt.Errorf("dashboard %v was changed in grafana to version %v by %v", uid, live["version"], updatedBy)
+		}
+	case conflictLoaderOwned:
+		if updatedBy != util.GetGrafanaUser() {
+			return nil, 0, fmt.Errorf("dashboard %v was changed in grafana to version %v by %v", uid, live["version"], updatedBy)
+		}
+	}
+
+	klog.Infof("dashboard %v is at version %v in grafana, update it on top of the version", uid, live["version"])
+	resolved := map[string]interface{}{}
+	for k, v := range dashboard {
+		resolved[k] = v
+	}
+	resolved["version"] = live["version"]
+	retried := map[string]interface{}{}
+	for k, v := range data {
+		retried[k] = v
+	}
+	retried["dashboard"] = resolved
+
+	body, respStatusCode, err := postDashboardData(retried)
+	if err != nil {
+		return nil, 0, err
+	}
+	if respStatusCode == http.StatusPreconditionFailed {
+		return nil, 0, fmt.Errorf("dashboard %v was changed in grafana again while resolving the version conflict", uid)
+	}
+	return body, respStatusCode, nil
+}
+
+// recordSavedVersion records the version of the dashboard in the response of saving it on the
+// configmap, only the configmaps with the refuse policy need it
+func recordSavedVersion(cm *corev1.ConfigMap, uid string, body []byte) {
+	if getConflictPolicy(cm) != conflictRefuse {
+		return
+	}
+	resp := map[string]interface{}{}
+	if json.Unmarshal(body, &resp) != nil {
+		return
+	}
+	version, ok := resp["version"].(float64)
+	if !ok {
+		return
+	}
+	err := updateConfigmapAnnotation(cm, savedVersionsKey, func(value string) string {
+		versions := map[string]float64{}
+		if value != "" {
+			json.Unmarshal([]byte(value), &versions)
+		}
+		versions[uid] = version
+		b, _ := json.Marshal(versions)
+		return string(b)
+	})
+	if err != nil {
+		klog.Errorf("failed to record the saved version of dashboard %v: %v", uid, err)
+	}
+}
+
+// getSavedVersion returns the version of the dashboard last saved by the loader recorded on
+// the configmap
+func getSavedVersion(cm *corev1.ConfigMap, uid string) (float64, bool) {
+	value, ok := cm.Annotations[savedVersionsKey]
+	if !ok {
+		return 0, false
+	}
+	versions := map[string]float64{}
+	err := json.Unmarshal([]byte(value), &versions)
+	if err != nil {
+		klog.Warningf("invalid %v annotation of %v: %v", savedVersionsKey, getConfigmapName(cm), err)
+		return 0, false
+	}
+	version, ok := versions[uid]
+	return version, ok
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+func TestGetConflictPolicy(t *testing.T) {
+	testCaseList := []struct {
+		name        string
+		annotations map[string]string
+		expected    string
+	}{
+		{"default", nil, conflictLoaderOwned},
+		{"overwrite", map[string]string{conflictPolicyKey: "overwrite"}, conflictOverwrite},
+		{"refuse", map[string]string{conflictPolicyKey: "refuse"}, conflictRefuse},
+		{"invalid", map[string]string{conflictPolicyKey: "always"}, conflictLoaderOwned},
+	}
+
+	for _, c := range testCaseList {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: c.annotations}}
+		output := getConflictPolicy(cm)
+		if output != c.expected {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
+
+func TestResolveVersionConflict(t *testing.T) {
+	updatedBy := ""
+	posted := []map[string]interface{}{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/dashboards/uid/test", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"dashboard": {"uid": "test", "version": 7}, "meta": {"updatedBy": "` + updatedBy + `"}}`))
+	})
+	mux.HandleFunc("/api/dashboards/db", func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		data := map[string]interface{}{}
+		json.Unmarshal(b, &data)
+		posted = append(posted, data)
+		w.Write([]byte("{}"))
+	})
+	startGrafanaServer(t, mux)
+
+	testCaseList := []struct {
+		name      string
+		policy    string
+		updatedBy string
+		saved     float64
+		resolved  bool
+	}{
+		{"loader-owned saved by the loader", conflictLoaderOwned, util.GetGrafanaUser(), 0, true},
+		{"loader-owned saved by a user", conflictLoaderOwned, "admin", 0, false},
+		{"overwrite saved by a user", conflictOverwrite, "admin", 0, true},
+		{"refuse saved by the loader", conflictRefuse, util.GetGrafanaUser(), 7, true},
+		{"refuse saved by the loader before the versions were recorded", conflictRefuse, util.GetGrafanaUser(), 0, true},
+		{"refuse saved by the loader since the recorded version", conflictRefuse, util.GetGrafanaUser(), 6, false},
+		{"refuse restored to an older version", conflictRefuse, util.GetGrafanaUser(), 8, false},
+		{"refuse saved by a user", conflictRefuse, "admin", 7, false},
+	}
+
+	for _, c := range testCaseList {
+		updatedBy = c.updatedBy
+		posted = []map[string]interface{}{}
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Annotations: map[string]string{conflictPolicyKey: c.policy},
+		}}
+		if c.saved > 0 {
+			cm.Annotations[savedVersionsKey] = fmt.Sprintf(`{"test": %v}`, c.saved)
+		}
+		data := map[string]interface{}{
+			"folderId":  0,
+			"overwrite": false,
+			"dashboard": map[string]interface{}{"uid": "test", "version": 1},
+		}
+
+		_, status, err := resolveVersionConflict(cm, data)
+		if (err == nil) != c.resolved {
+			t.Errorf("case (%v) output: (%v) is not the expected resolved: (%v)", c.name, err, c.resolved)
+			continue
+		}
+		if !c.resolved {
+			if len(posted) != 0 {
+				t.Errorf("case (%v) should not post the dashboard", c.name)
+			}
+			continue
+		}
+		if status != http.StatusOK || len(posted) != 1 {
+			t.Fatalf("case (%v) posted %v with %v", c.name, posted, status)
+		}
+		dashboard := posted[0]["dashboard"].(map[string]interface{})
+		if dashboard["version"] != 7.0 || posted[0]["overwrite"] != false {
+			t.Errorf("case (%v) posted %v instead of the live version without overwrite", c.name, posted[0])
+		}
+	}
+}
+
+func TestRecordSavedVersion(t *testing.T) {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:        "test",
+		Namespace:   "ns",
+		UID:         "1",
+		Annotations: map[string]string{conflictPolicyKey: conflictRefuse},
+	}}
+	configmapClient = fake.NewSimpleClientset(cm).CoreV1()
+	defer func() { configmapClient = nil }()
+
+	recordSavedVersion(cm, "test", []byte(`{"uid": "test", "version": 8}`))
+	latest, _ := configmapClient.ConfigMaps("ns").Get(context.TODO(), "test", metav1.GetOptions{})
+	version, found := getSavedVersion(latest, "test")
+	if !found || version != 8 {
+		t.Errorf("the saved version %v should be recorded in %v", version, latest.Annotations)
+	}
+
+	// the versions are only recorded for the refuse policy
+	other := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "ns", UID: "2"}}
+	configmapClient = fake.NewSimpleClientset(other).CoreV1()
+	recordSavedVersion(other, "test", []byte(`{"uid": "test", "version": 8}`))
+	latest, _ = configmapClient.ConfigMaps("ns").Get(context.TODO(), "other", metav1.GetOptions{})
+	if _, found := latest.Annotations[savedVersionsKey]; found {
+		t.Errorf("the saved version should not be recorded in %v", latest.Annotations)
+	}
+}
@@ -10,14 +10,15 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"regexp"
-	"strconv"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -33,7 +34,11 @@ const (
 	customFolderKey     = "observability.open-cluster-management.io/dashboard-folder"
 	generalFolderKey    = "general-folder"
 	defaultCustomFolder = "Custom"
-	homeDashboardTitle  = "ACM - Clusters Overview"
+	// homeDashboardTitle is set as org home dashboard when no configmap sets one
+	homeDashboardTitle = "ACM - Clusters Overview"
+	// dryRunFolderID and dryRunFolderUID stand for folders which would be created in dry run mode
+	dryRunFolderID  = -1
+	dryRunFolderUID = "dry-run"
 )
 
 // DashboardLoader ...
@@ -60,7 +65,15 @@ func RunGrafanaDashboardController(stop <-chan struct{}) {
 		klog.Fatal("Failed to build kubeclient", "error", err)
 	}
 
-	go newKubeInformer(kubeClient.CoreV1()).Run(stop)
+	addClientConfigmapLookup(kubeClient.CoreV1())
+	startEventRecorder(kubeClient.CoreV1())
+	configmapClient = kubeClient.CoreV1()
+	syncDatasources(kubeClient.CoreV1())
+	syncLibraryPanels(kubeClient.CoreV1())
+	queue := newConfigmapQueue(getConfigmapHandlers(kubeClient.CoreV1()))
+	go queue.run(Workers, stop)
+	go newKubeInformer(kubeClient.CoreV1(), queue).Run(stop)
+	go wait.Until(func() { resyncDatasourceSecrets(kubeClient.CoreV1(), queue) }, DatasourceSecretResyncPeriod, stop)
 	<-stop
 }
 
@@ -85,7 +98,104 @@ func isDesiredDashboardConfigmap(obj interface{}) bool {
 	return false
 }
 
-func newKubeInformer(coreClient corev1client.CoreV1Interface) cache.SharedIndexInformer {
+// configmapHandler syncs one kind of configmaps to grafana
+type configmapHandler struct {
+	kind      string
+	isDesired func(obj interface{}) bool
+	// update returns the errors of the update, the retryable ones are retried
+	update func(old, new interface{}) error
+	delete func(obj interface{})
+	// finalize cleans up the configmap being deleted, nil for kinds without finalizers
+	finalize func(obj interface{}) error
+}
+
+// getConfigmapHandlers returns the handlers of all kinds of configmaps, a configmap
+// is handled by the first handler it is desired by
+func getConfigmapHandlers(coreClient corev1client.CoreV1Interface) []configmapHandler {
+	return []configmapHandler{
+		{
+			kind:      "datasource",
+			isDesired: isDatasourceConfigmap,
+			update: func(old, new interface{}) error {
+				updateDatasources(coreClient, old, new)
+				return nil
+			},
+			delete: deleteDatasources,
+		},
+		{
+			kind:      "library panel",
+			isDesired: isLibraryPanelConfigmap,
+			update: func(old, new interface{}) error {
+				updateLibraryPanels(old, new)
+				return nil
+			},
+			delete: deleteLibraryPanels,
+		},
+		{
+			kind:      "alert rule",
+			isDesired: isAlertRuleConfigmap,
+			update: func(old, new interface{}) error {
+				updateAlertRules(old, new)
+				return nil
+			},
+			delete: deleteAlertRules,
+		},
+		{
+			kind:      "playlist",
+			isDesired: isPlaylistConfigmap,
+			update: func(old, new interface{}) error {
+				updatePlaylists(old, new)
+				return nil
+			},
+			delete: deletePlaylists,
+		},
+		{
+			kind:      "annotation",
+			isDesired: isAnnotationConfigmap,
+			update: func(old, new interface{}) error {
+				updateAnnotations(new)
+				return nil
+			},
+			delete: func(obj interface{}) {
+				deleteAnnotations(obj)
+			},
+		},
+		{
+			kind:      "dashboard",
+			isDesired: isDesiredDashboardConfigmap,
+			update: func(old, new interface{}) error {
+				addDashboardFinalizer(coreClient, new)
+				return updateDashboard(old, new, false)
+			},
+			delete: deleteDashboard,
+			finalize: func(obj interface{}) error {
+				return finalizeDashboard(coreClient, obj)
+			},
+		},
+		{
+			// the configmaps whose dashboard label was removed still need to be finalized
+			kind:      "unlabeled dashboard",
+			isDesired: isFinalizedDashboardConfigmap,
+			update:    func(old, new interface{}) error { return nil },
+			delete:    func(obj interface{}) {},
+			finalize: func(obj interface{}) error {
+				return finalizeDashboard(coreClient, obj)
+			},
+		},
+	}
+}
+
+func getConfigmapHandler(handlers []configmapHandler, obj interface{}) *configmapHandler {
+	for i := range handlers {
+		if handlers[i].isDesired(obj) {
+			return &handlers[i]
+		}
+	}
+	return nil
+}
+
+// newKubeInformer returns the informer queueing the changed configmaps to the queue
+func newKubeInformer(coreClient corev1client.CoreV1Interface, queue *configmapQueue) cache.SharedIndexInformer {
 	// get watched namespace
 	watchedNS := os.Getenv("POD_NAMESPACE")
 	watchlist := &cache.ListWatch{
@@ -105,28 +215,29 @@ func newKubeInformer(coreClient corev1client.CoreV1Interface) cache.SharedIndexI
 
 	kubeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			if !isDesiredDashboardConfigmap(obj) {
+			if getConfigmapHandler(queue.handlers, obj) == nil {
 				return
 			}
-			klog.Infof("detect there is a new dashboard %v created", obj.(*corev1.ConfigMap).Name)
-			updateDashboard(nil, obj, false)
+			queue.add(nil, obj)
 		},
 		UpdateFunc: func(old, new interface{}) {
 			if old.(*corev1.ConfigMap).ObjectMeta.ResourceVersion == new.(*corev1.ConfigMap).ObjectMeta.ResourceVersion {
 				return
 			}
-			if !isDesiredDashboardConfigmap(new) {
+			if getConfigmapHandler(queue.handlers, new) == nil {
 				return
 			}
-			klog.Infof("detect there is a dashboard %v updated", new.(*corev1.ConfigMap).Name)
-			updateDashboard(old, new, false)
+			queue.add(old, new)
 		},
 		DeleteFunc: func(obj interface{}) {
-			if !isDesiredDashboardConfigmap(obj) {
+			// the final state of configmaps deleted while the watch was disconnected
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if getConfigmapHandler(queue.handlers, obj) == nil {
 				return
 			}
-			klog.Infof("detect there is a dashboard %v deleted", obj.(*corev1.ConfigMap).Name)
-			deleteDashboard(obj)
+			queue.addDeleted(obj)
 		},
 	})
 
@@ -134,41 +245,66 @@ func newKubeInformer(coreClient corev1client.CoreV1Interface) cache.SharedIndexI
 }
 
 func hasCustomFolder(folderTitle string) float64 {
-	grafanaURL := grafanaURI + "/api/folders"
-	body, _ := util.SetRequest("GET", grafanaURL, nil, retry)
-
-	folders := []map[string]interface{}{}
-	err := json.Unmarshal(body, &folders)
-	if err != nil {
-		klog.Error(unmarshallErrMsg, "error", err)
+	if folderTitle == "" {
 		return 0
 	}
-
-	for _, folder := range folders {
-		if folder["title"] == folderTitle {
-			return folder["id"].(float64)
-		}
+	folder, ok := folders.getByTitle(folderTitle)
+	if !ok {
+		return 0
 	}
-	return 0
+	return folder.id
 }
 
+// folderMutex serializes the creation of folders, so that concurrent dashboards in a new
+// folder create it once
+var folderMutex sync.Mutex
+
+// folderDeletionMutex keeps the empty folders from being deleted while dashboards, library
+// panels or alert rules are written to them, as deleting a folder deletes them too
+var folderDeletionMutex sync.RWMutex
+
 func createCustomFolder(folderTitle string) float64 {
+	folderMutex.Lock()
+	defer folderMutex.Unlock()
 	folderID := hasCustomFolder(folderTitle)
 	if folderID == 0 {
+		b, err := json.Marshal(map[string]string{
+			"uid":   getLoaderFolderUID(folderTitle),
+			"title": folderTitle,
+		})
+		if err != nil {
+			klog.Error("failed to marshal body", "error", err)
+			return 0
+		}
 		grafanaURL := grafanaURI + "/api/folders"
-		body, _ := util.SetRequest("POST", grafanaURL, strings.NewReader("{\"title\":\""+folderTitle+"\"}"), retry)
+		body, _ := util.SetRequest("POST", grafanaURL, bytes.NewBuffer(b), retry)
 		folder := map[string]interface{}{}
-		err := json.Unmarshal(body, &folder)
+		err = json.Unmarshal(body, &folder)
 		if err != nil {
 			klog.Error(unmarshallErrMsg, "error", err)
 			return 0
 		}
-		return folder["id"].(float64)
+		id, ok := folder["id"].(float64)
+		if !ok && util.DryRun {
+			return dryRunFolderID
+		}
+		if ok {
+			uid, _ := folder["uid"].(string)
+			folders.add(grafanaFolder{id: id, uid: uid, title: folderTitle})
+		}
+		return id
 	}
 	return folderID
 }
 
 func getCustomFolderUID(folderID float64) string {
+	if folderID == dryRunFolderID {
+		return dryRunFolderUID
+	}
+	if folder, ok := folders.getByID(folderID); ok && folder.uid != "" {
+		return folder.uid
+	}
+
 	grafanaURL := grafanaURI + "/api/folders/id/" + fmt.Sprint(folderID)
 	body, _ := util.SetRequest("GET", grafanaURL, nil, retry)
 	folder := map[string]interface{}{}
@@ -185,10 +321,55 @@ func getCustomFolderUID(folderID float64) string {
 	return ""
 }
 
+// isDeletableFolder returns whether the folder was created by the loader and nothing is left
+// in it, as deleting a folder in grafana deletes its alert rules and library elements too
+func isDeletableFolder(folderID float64) bool {
+	if folderID == 0 {
+		return false
+	}
+	folder, ok := folders.getByID(folderID)
+	if !ok {
+		return false
+	}
+	if !folder.isLoaderFolder() {
+		klog.V(2).Infof("folder %v was not created by the loader, keep it", folder.title)
+		return false
+	}
+	if !isEmptyFolder(folderID) {
+		return false
+	}
+	if hasFolderAlertRules(folder.uid) {
+		klog.Infof("folder %v has alert rules, keep it", folder.title)
+		return false
+	}
+	if hasFolderLibraryElements(folderID) {
+		klog.Infof("folder %v has library elements, keep it", folder.title)
+		return false
+	}
+	return true
+}
+
+// deleteEmptyFolder deletes the folder when it is deletable, it returns whether the folder
+// was deletable and the error when it could not be deleted
+func deleteEmptyFolder(folderID float64) (bool, error) {
+	folderDeletionMutex.Lock()
+	defer folderDeletionMutex.Unlock()
+	if !isDeletableFolder(folderID) {
+		return false, nil
+	}
+	if !deleteCustomFolder(folderID) {
+		return true, fmt.Errorf("failed to delete folder %v", folderID)
+	}
+	return true, nil
+}
+
 func isEmptyFolder(folderID float64) bool {
 	if folderID == 0 {
 		return false
 	}
+	if _, ok := folders.getByID(folderID); !ok {
+		return false
+	}
 
 	grafanaURL := grafanaURI + "/api/search?folderIds=" + fmt.Sprint(folderID)
 	body, _ := util.SetRequest("GET", grafanaURL, nil, retry)
@@ -221,10 +402,14 @@ func deleteCustomFolder(folderID float64) bool {
 	grafanaURL := grafanaURI + "/api/folders/" + uid
 	_, respStatusCode := util.SetRequest("DELETE", grafanaURL, nil, retry)
 	if respStatusCode != http.StatusOK {
+		if respStatusCode == http.StatusNotFound {
+			folders.remove(folderID)
+		}
 		klog.Errorf("failed to delete custom folder %v with %v", folderID, respStatusCode)
 		return false
 	}
 
+	folders.remove(folderID)
 	klog.Infof("custom folder %v deleted", folderID)
 	return true
 }
@@ -247,135 +432,333 @@ func getDashboardCustomFolderTitle(obj interface{}) string {
 	return ""
 }
 
-// updateDashboard is used to update the customized dashboards via calling grafana api
-func updateDashboard(old, new interface{}, overwrite bool) {
-	folderID := 0.0
+// getDashboardUID returns the uid of the dashboard, generated from the configmap if it is not set
+func getDashboardUID(cm *corev1.ConfigMap, dashboard map[string]interface{}) string {
+	if uid, ok := dashboard["uid"].(string); ok && uid != "" {
+		return uid
+	}
+	uid, _ := util.GenerateUID(cm.GetName(), cm.GetNamespace())
+	return uid
+}
+
+// getDashboardKeys returns the sorted data keys of the configmap's dashboards
+func getDashboardKeys(cm *corev1.ConfigMap) []string {
+	keys := []string{}
+	for key := range cm.Data {
+		if !isJsonnetLibraryKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderDashboard returns the dashboard JSON of the configmap's data key, rendering
+// the template first and then the jsonnet
+func renderDashboard(cm *corev1.ConfigMap, key string) (string, error) {
+	data := cm.Data[key]
+	if isTemplateConfigmap(cm) {
+		var err error
+		data, err = renderTemplate(cm, key)
+		if err != nil {
+			return "", fmt.Errorf("failed to render template: %v", err)
+		}
+	}
+	if isJsonnetKey(key) {
+		return renderJsonnet(cm, key, data)
+	}
+	return data, nil
+}
+
+// dashboardJSONError is the error unmarshalling the rendered dashboard, the offsets of
+// syntax errors are in the rendered data rather than in the configmap
+type dashboardJSONError struct {
+	data string
+	err  error
+}
+
+func (e *dashboardJSONError) Error() string {
+	return e.err.Error()
+}
+
+// parseDashboard parses the dashboard of the configmap's data key, with its uid set
+func parseDashboard(cm *corev1.ConfigMap, key string) (map[string]interface{}, error) {
+	data, err := renderDashboard(cm, key)
+	if err != nil {
+		return nil, err
+	}
+	dashboard := map[string]interface{}{}
+	err = json.Unmarshal([]byte(data), &dashboard)
+	if err != nil {
+		return nil, &dashboardJSONError{data: data, err: err}
+	}
+	dashboard, err = resolveGnetDashboard(dashboard)
+	if err != nil {
+		return nil, err
+	}
+	err = applyDashboardInputs(cm, dashboard)
+	if err != nil {
+		return nil, err
+	}
+	dashboard["uid"] = getDashboardUID(cm, dashboard)
+	if adopted, ok := getAdoptedDashboardUIDs(cm)[key]; ok && adopted != "" {
+		dashboard["uid"] = adopted
+	}
+	return dashboard, nil
+}
+
+// updateDashboard is used to update the customized dashboards via calling grafana api,
+// it returns an error listing the dashboards which failed to load
+func updateDashboard(old, new interface{}, overwrite bool) error {
+	cm := new.(*corev1.ConfigMap)
 	folderTitle := getDashboardCustomFolderTitle(new)
+	homeScopes := getHomeDashboardScopes(new)
+	homeKey := getHomeDashboardDataKey(new)
+
+	// render all dashboards first to skip grafana when none of them changed
+	keys := []string{}
+	dashboards := map[string]map[string]interface{}{}
+	hashes := map[string]string{}
+	uids := map[string]bool{}
+	for _, key := range getDashboardKeys(cm) {
+		dashboard, err := parseDashboard(cm, key)
+		if err != nil {
+			err = fmt.Errorf("failed to parse dashboard %v: %v", key, err)
+			recordWarning(cm, "InvalidDashboard", err.Error())
+			return err
+		}
+		uid := dashboard["uid"].(string)
+		uids[uid] = true
+
+		scopes := []string{}
+		if len(homeScopes) > 0 && key == homeKey {
+			scopes = homeScopes
+		} else if len(homeScopes) == 0 && dashboard["title"] == homeDashboardTitle {
+			scopes = []string{orgScope}
+		}
+		hash := getDashboardHash(dashboard, folderTitle, scopes)
+		if isDashboardUnchanged(uid, hash) {
+			klog.V(2).Infof("dashboard %v is unchanged", uid)
+			if len(scopes) > 0 {
+				restoreHomeDashboard(cm, uid, scopes)
+			}
+			recordLibraryPanelRefs(uid, getLibraryPanelRefs(dashboard))
+			continue
+		}
+		keys = append(keys, key)
+		dashboards[key] = dashboard
+		hashes[key] = hash
+	}
+
+	// delete the dashboards first, so that a dashboard whose uid changed does not conflict
+	// with its old uid
+	failures := []string{}
+	for _, uid := range getRemovedDashboardUIDs(old, uids) {
+		klog.Infof("dashboard %v was removed from %v", uid, getConfigmapName(cm))
+		if !deleteDashboardByUID(uid) {
+			failures = append(failures, fmt.Sprintf("failed to delete removed dashboard %v", uid))
+		}
+	}
+	// the removed dashboards are deleted again when retried
+	retryable := len(failures) > 0
+	if len(keys) == 0 {
+		unsetRemovedHomeDashboard(old, new)
+		deleteOldFolder(old, new)
+		return joinDashboardFailures(failures, retryable)
+	}
+
+	posted, postRetryable := postDashboards(cm, keys, dashboards, hashes, overwrite)
+	failures = append(failures, posted...)
+	unsetRemovedHomeDashboard(old, new)
+	deleteOldFolder(old, new)
+	return joinDashboardFailures(failures, retryable || postRetryable)
+}
+
+// postDashboards posts the dashboards of the keys to the folder of the configmap, it returns
+// the failures and whether any of them is retryable, the folder is not deleted meanwhile
+func postDashboards(cm *corev1.ConfigMap, keys []string, dashboards map[string]map[string]interface{},
+	hashes map[string]string, overwrite bool) ([]string, bool) {
+	folderDeletionMutex.RLock()
+	defer folderDeletionMutex.RUnlock()
+	folderTitle := getDashboardCustomFolderTitle(cm)
+	homeScopes := getHomeDashboardScopes(cm)
+	homeKey := getHomeDashboardDataKey(cm)
+
+	folderID := 0.0
 	if folderTitle != "" {
 		folderID = createCustomFolder(folderTitle)
 		if folderID == 0 {
 			klog.Error("Failed to get custom folder id")
-			return
+			return []string{fmt.Sprintf("failed to get custom folder %v", folderTitle)}, true
 		}
 	}
 
-	for _, value := range new.(*corev1.ConfigMap).Data {
-
-		dashboard := map[string]interface{}{}
-		err := json.Unmarshal([]byte(value), &dashboard)
-		if err != nil {
-			klog.Error("Failed to unmarshall data", "error", err)
-			return
-		}
-		if dashboard["uid"] == nil {
-			dashboard["uid"], _ = util.GenerateUID(new.(*corev1.ConfigMap).GetName(),
-				new.(*corev1.ConfigMap).GetNamespace())
-		}
+	failures := []string{}
+	retryable := false
+	for _, key := range keys {
+		dashboard := dashboards[key]
+		uid := dashboard["uid"].(string)
 		dashboard["id"] = nil
 		data := map[string]interface{}{
 			"folderId":  folderID,
 			"overwrite": overwrite,
-			"dashboard": dashboard,
+			"dashboard": setDashboardHashTag(dashboard, hashes[key]),
 		}
 
 		b, err := json.Marshal(data)
 		if err != nil {
 			klog.Error("failed to marshal body", "error", err)
-			return
+			failures = append(failures, fmt.Sprintf("%v: %v", key, err))
+			continue
 		}
 
 		grafanaURL := grafanaURI + "/api/dashboards/db"
 		body, respStatusCode := util.SetRequest("POST", grafanaURL, bytes.NewBuffer(b), retry)
+		if respStatusCode == http.StatusPreconditionFailed && strings.Contains(string(body), "version-mismatch") {
+			body, respStatusCode, err = resolveVersionConflict(cm, data)
+			if err != nil {
+				recordWarning(cm, "DashboardConflict", err.Error())
+				failures = append(failures, fmt.Sprintf("%v: %v", key, err))
+				continue
+			}
+		}
+		if respStatusCode == http.StatusPreconditionFailed && strings.Contains(string(body), "name-exists") {
+			body, respStatusCode, err = resolveNameConflict(cm, key, data)
+			if err != nil {
+				recordWarning(cm, "DashboardNameConflict", err.Error())
+				failures = append(failures, fmt.Sprintf("%v: %v", key, err))
+				continue
+			}
+		}
 
 		if respStatusCode != http.StatusOK {
-			if respStatusCode == http.StatusPreconditionFailed {
-				if strings.Contains(string(body), "version-mismatch") {
-					updateDashboard(nil, new, true)
-				} else if strings.Contains(string(body), "name-exists") {
-					klog.Info("the dashboard name already existed")
-				} else {
-					klog.Infof("failed to create/update: %v", respStatusCode)
-				}
-			} else {
-				klog.Infof("failed to create/update: %v", respStatusCode)
+			if respStatusCode == http.StatusNotFound || strings.Contains(strings.ToLower(string(body)), "folder not found") {
+				// the cached folder was deleted from grafana, it is created again when retried
+				folders.remove(folderID)
+				retryable = true
 			}
+			retryable = retryable || isRetryableStatus(respStatusCode)
+			klog.Infof("failed to create/update: %v", respStatusCode)
+			failures = append(failures, fmt.Sprintf("%v: %v", key, respStatusCode))
 		} else {
-			if dashboard["title"] == homeDashboardTitle {
-				// get "id" value from response
-				re := regexp.MustCompile("\"id\":(\\d+),")
-				result := re.FindSubmatch(body)
-				if len(result) != 2 {
-					klog.Infof("failed to retrieve dashboard id")
-				} else {
-					id, err := strconv.Atoi(strings.Trim(string(result[1]), " "))
-					if err != nil {
-						klog.Error(err, "failed to parse dashboard id")
-					} else {
-						setHomeDashboard(id)
-					}
-				}
+			// e.g. the uid of an adopted dashboard
+			uid = getResponseUID(body, uid)
+			if len(homeScopes) > 0 && key == homeKey {
+				setHomeDashboard(cm, uid, homeScopes)
+			} else if len(homeScopes) == 0 && dashboard["title"] == homeDashboardTitle {
+				setHomeDashboard(cm, uid, []string{orgScope})
+			}
+			recordLibraryPanelRefs(uid, getLibraryPanelRefs(dashboard))
+			recordSavedVersion(cm, uid, body)
+			if !util.DryRun {
+				recordDashboardHash(uid, hashes[key])
 			}
 			klog.Info("Dashboard created/updated")
 		}
 	}
+	return failures, retryable
+}
 
-	folderTitle = getDashboardCustomFolderTitle(old)
-	folderID = hasCustomFolder(folderTitle)
-	if isEmptyFolder(folderID) {
-		deleteCustomFolder(folderID)
+func joinDashboardFailures(failures []string, retryable bool) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	err := fmt.Errorf("failed to create/update %v", strings.Join(failures, ", "))
+	if retryable {
+		return &retryableError{err}
 	}
+	return err
 }
 
-// DeleteDashboard ...
-func deleteDashboard(obj interface{}) {
-	for _, value := range obj.(*corev1.ConfigMap).Data {
+// getRemovedDashboardUIDs returns the sorted uids of the dashboards of the old configmap which
+// are not in uids, e.g. when a data key is removed or the uid of its dashboard changed
+func getRemovedDashboardUIDs(old interface{}, uids map[string]bool) []string {
+	cm, ok := old.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return nil
+	}
 
-		dashboard := map[string]interface{}{}
-		err := json.Unmarshal([]byte(value), &dashboard)
+	removed := []string{}
+	seen := map[string]bool{}
+	for _, key := range getDashboardKeys(cm) {
+		dashboard, err := parseDashboard(cm, key)
 		if err != nil {
-			klog.Error("Failed to unmarshall data", "error", err)
-			return
+			// the dashboard could not have been loaded
+			continue
 		}
-
-		uid, _ := util.GenerateUID(obj.(*corev1.ConfigMap).Name, obj.(*corev1.ConfigMap).Namespace)
-		if dashboard["uid"] != nil {
-			uid = dashboard["uid"].(string)
+		if uid := dashboard["uid"].(string); !uids[uid] && !seen[uid] {
+			seen[uid] = true
+			removed = append(removed, uid)
 		}
+	}
+	sort.Strings(removed)
+	return removed
+}
 
-		grafanaURL := grafanaURI + "/api/dashboards/uid/" + uid
+// deleteOldFolder deletes the folder of the old configmap when the dashboards left it empty,
+// the folder of the new configmap is only deleted when it has no dashboards
+func deleteOldFolder(old, new interface{}) {
+	folderTitle := getDashboardCustomFolderTitle(old)
+	if folderTitle == "" {
+		return
+	}
+	cm, _ := new.(*corev1.ConfigMap)
+	if folderTitle == getDashboardCustomFolderTitle(new) && cm != nil && len(getDashboardKeys(cm)) > 0 {
+		return
+	}
 
-		_, respStatusCode := util.SetRequest("DELETE", grafanaURL, nil, retry)
-		if respStatusCode != http.StatusOK {
-			klog.Errorf("failed to delete dashboard %v with %v", obj.(*corev1.ConfigMap).Name, respStatusCode)
-		} else {
-			klog.Info("Dashboard deleted")
-		}
+	deleteEmptyFolder(hasCustomFolder(folderTitle))
+}
 
-		folderTitle := getDashboardCustomFolderTitle(obj)
-		folderID := hasCustomFolder(folderTitle)
-		if isEmptyFolder(folderID) {
-			deleteCustomFolder(folderID)
-		}
+// deleteDashboardByUID deletes the dashboard and releases the references to it
+func deleteDashboardByUID(uid string) bool {
+	grafanaURL := grafanaURI + "/api/dashboards/uid/" + uid
+	_, respStatusCode := util.SetRequest("DELETE", grafanaURL, nil, retry)
+	if respStatusCode == http.StatusNotFound {
+		klog.Infof("dashboard %v was deleted already", uid)
+	} else if respStatusCode != http.StatusOK {
+		klog.Errorf("failed to delete dashboard %v with %v", uid, respStatusCode)
+		return false
 	}
-	return
+
+	klog.Info("Dashboard deleted")
+	forgetDashboardHash(uid)
+	unsetHomeDashboard(uid, nil)
+	releaseLibraryPanelRefs(uid)
+	return true
 }
 
-func setHomeDashboard(id int) {
-	data := map[string]int{
-		"homeDashboardId": id,
-	}
+// DeleteDashboard ...
+func deleteDashboard(obj interface{}) {
+	deleteDashboards(obj.(*corev1.ConfigMap))
+}
 
-	b, err := json.Marshal(data)
-	if err != nil {
-		klog.Error("failed to marshal body", "error", err)
-		return
+// deleteDashboards deletes the dashboards of the configmap, and its folder when left empty
+func deleteDashboards(cm *corev1.ConfigMap) error {
+	failures := []string{}
+	for _, key := range getDashboardKeys(cm) {
+		uid := ""
+		dashboard, err := parseDashboard(cm, key)
+		if err == nil {
+			uid = dashboard["uid"].(string)
+		} else if adopted, ok := getAdoptedDashboardUIDs(cm)[key]; ok {
+			uid = adopted
+		} else {
+			// the dashboard was likely never loaded, a dashboard loaded with a uid of its own
+			// before it became invalid is left in grafana
+			klog.Warningf("failed to parse dashboard %v of %v, delete it by its generated uid: %v", key, getConfigmapName(cm), err)
+			uid = getDashboardUID(cm, nil)
+		}
+		if !deleteDashboardByUID(uid) {
+			failures = append(failures, uid)
+		}
 	}
-	grafanaURL := grafanaURI + "/api/org/preferences"
-	_, respStatusCode := util.SetRequest("PUT", grafanaURL, bytes.NewBuffer(b), retry)
 
-	if respStatusCode != http.StatusOK {
-		klog.Infof("failed to set home dashboard: %v", respStatusCode)
-	} else {
-		klog.Info("Home dashboard is set")
+	folderTitle := getDashboardCustomFolderTitle(cm)
+	deleteEmptyFolder(hasCustomFolder(folderTitle))
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to delete dashboards %v", strings.Join(failures, ", "))
 	}
+	return nil
 }
@@ -5,9 +5,15 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +21,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
 )
 
 var (
@@ -80,13 +88,161 @@ func createFakeServer(t *testing.T) {
 
 	server3001.HandleFunc("/api/dashboards/uid/ff635a025bcfea7bc3dd4f508990a3e8",
 		func(w http.ResponseWriter, req *http.Request) {
+			if req.Method == "GET" {
+				w.Write([]byte("{\"dashboard\": {\"id\": 7, \"uid\": \"ff635a025bcfea7bc3dd4f508990a3e8\", " +
+					"\"title\": \"Networking\", \"version\": 3}, \"meta\": {\"folderId\": 1, \"folderTitle\": \"Custom\"}}"))
+				return
+			}
 			w.Write([]byte("done"))
 		},
 	)
 
+	server3001.HandleFunc("/api/org/preferences",
+		func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("{\"theme\": \"dark\", \"homeDashboardId\": 0, \"homeDashboardUID\": \"previous\"}"))
+		},
+	)
+
+	server3001.HandleFunc("/api/teams/search",
+		func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("{\"teams\": [{\"id\": 1, \"name\": \"sre\"}]}"))
+		},
+	)
+
+	server3001.HandleFunc("/api/teams/1/preferences",
+		func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("{\"homeDashboardUID\": \"\"}"))
+		},
+	)
+
+	server3001.HandleFunc("/api/users/lookup",
+		func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Query().Get("loginOrEmail") != "alice" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte("{\"id\": 2, \"login\": \"alice\"}"))
+		},
+	)
+
+	server3001.HandleFunc("/api/user/preferences",
+		func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("X-Forwarded-User") != "alice" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte("{\"homeDashboardUID\": \"\"}"))
+		},
+	)
+
+	server3001.HandleFunc("/api/library-elements",
+		func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("{}"))
+		},
+	)
+
+	server3001.HandleFunc("/api/library-elements/",
+		func(w http.ResponseWriter, req *http.Request) {
+			if strings.HasSuffix(req.URL.Path, "/connections") {
+				if req.URL.Path == "/api/library-elements/connected/connections" {
+					w.Write([]byte("{\"result\": [{\"id\": 1}]}"))
+					return
+				}
+				w.Write([]byte("{\"result\": []}"))
+				return
+			}
+			if req.Method == "GET" && req.URL.Path != "/api/library-elements/existing" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte("{\"result\": {\"uid\": \"existing\", \"version\": 2}}"))
+		},
+	)
+
+	server3001.HandleFunc("/api/datasources",
+		func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("{}"))
+		},
+	)
+
+	server3001.HandleFunc("/api/datasources/uid/",
+		func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/api/datasources/uid/existing" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte("{}"))
+		},
+	)
+
+	server3001.HandleFunc("/api/playlists",
+		func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("{}"))
+		},
+	)
+
+	server3001.HandleFunc("/api/playlists/",
+		func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/api/playlists/existing" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte("{}"))
+		},
+	)
+
+	server3001.HandleFunc("/api/annotations",
+		func(w http.ResponseWriter, req *http.Request) {
+			if req.Method == "GET" {
+				w.Write([]byte("[{\"id\": 1}]"))
+				return
+			}
+			w.Write([]byte("{}"))
+		},
+	)
+
+	server3001.HandleFunc("/api/annotations/1",
+		func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("{}"))
+		},
+	)
+
 	err := http.ListenAndServe(":3001", server3001)
 	if err != nil {
-		t.Fatal("fail to create internal server at 3001")
+		t.Error("fail to create internal server at 3001")
+	}
+}
+
+// startGrafanaServer serves the handler as grafana until the test ends, with the folder
+// cache emptied before and after
+func startGrafanaServer(t *testing.T, handler http.Handler) {
+	server := httptest.NewServer(handler)
+	previous := grafanaURI
+	grafanaURI = server.URL
+	folders = newFolderCache()
+	t.Cleanup(func() {
+		server.Close()
+		grafanaURI = previous
+		folders = newFolderCache()
+	})
+}
+
+// startFakeServer starts the fake grafana server once and waits until it listens
+func startFakeServer(t *testing.T) {
+	retry = 1
+	if hasFakeServer {
+		return
+	}
+
+	hasFakeServer = true
+	go createFakeServer(t)
+	for i := 0; i < 50; i++ {
+		conn, err := net.Dial("tcp", "127.0.0.1:3001")
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(time.Millisecond * 100)
 	}
 }
 
@@ -95,12 +251,13 @@ func TestGrafanaDashboardController(t *testing.T) {
 	coreClient := fake.NewSimpleClientset().CoreV1()
 	stop := make(chan struct{})
 
-	go createFakeServer(t)
-	retry = 1
+	startFakeServer(t)
 
 	os.Setenv("POD_NAMESPACE", "ns2")
 
-	informer := newKubeInformer(coreClient)
+	queue := newConfigmapQueue(getConfigmapHandlers(coreClient))
+	go queue.run(Workers, stop)
+	informer := newKubeInformer(coreClient, queue)
 	go informer.Run(stop)
 
 	cm, err := createDashboard()
@@ -231,12 +388,37 @@ func TestIsDesiredDashboardConfigmap(t *testing.T) {
 	}
 }
 
-func TestGetCustomFolderUID(t *testing.T) {
-	if !hasFakeServer {
-		go createFakeServer(t)
-		retry = 1
+func TestGetConfigmapHandler(t *testing.T) {
+	handlers := getConfigmapHandlers(fake.NewSimpleClientset().CoreV1())
+	testCaseList := []struct {
+		name     string
+		labels   map[string]string
+		expected string
+	}{
+		{"dashboard", map[string]string{"grafana-custom-dashboard": "true"}, "dashboard"},
+		{"datasource", map[string]string{datasourceLabel: "true"}, "datasource"},
+		{"library panel", map[string]string{libraryPanelLabel: "true"}, "library panel"},
+		{"alert rule", map[string]string{alertRuleLabel: "true"}, "alert rule"},
+		{"playlist", map[string]string{playlistLabel: "true"}, "playlist"},
+		{"annotation", map[string]string{annotationLabel: "true"}, "annotation"},
+		{"unknown", map[string]string{}, ""},
 	}
 
+	for _, c := range testCaseList {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: c.labels}}
+		output := ""
+		if handler := getConfigmapHandler(handlers, cm); handler != nil {
+			output = handler.kind
+		}
+		if output != c.expected {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
+
+func TestGetCustomFolderUID(t *testing.T) {
+	startFakeServer(t)
+
 	testCaseList := []struct {
 		name     string
 		id       float64
@@ -268,10 +450,7 @@ func TestGetCustomFolderUID(t *testing.T) {
 }
 
 func TestIsEmptyFolder(t *testing.T) {
-	if !hasFakeServer {
-		go createFakeServer(t)
-		retry = 1
-	}
+	startFakeServer(t)
 
 	testCaseList := []struct {
 		name     string
@@ -346,10 +525,7 @@ func TestGetDashboardCustomFolderTitle(t *testing.T) {
 }
 
 func TestDeleteCustomFolder(t *testing.T) {
-	if !hasFakeServer {
-		go createFakeServer(t)
-		retry = 1
-	}
+	startFakeServer(t)
 
 	testCaseList := []struct {
 		name     string
@@ -389,3 +565,139 @@ func TestDeleteCustomFolder(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateCustomFolderDryRun(t *testing.T) {
+	startFakeServer(t)
+	util.DryRun = true
+	defer func() { util.DryRun = false }()
+
+	if folderID := createCustomFolder("Custom"); folderID != 1 {
+		t.Errorf("the existing folder id %v is not the expected 1", folderID)
+	}
+	folderID := createCustomFolder("New")
+	if folderID != dryRunFolderID || getCustomFolderUID(folderID) != dryRunFolderUID {
+		t.Errorf("the folder id %v is not the expected dry run folder", folderID)
+	}
+}
+
+func TestUpdateDashboardMovesAndRemoves(t *testing.T) {
+	requests := []string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/folders", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[{"id": 1, "uid": "loader-old", "title": "Old"}, {"id": 2, "uid": "loader-new", "title": "New"}]`))
+	})
+	mux.HandleFunc("/api/folders/", func(w http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		w.Write([]byte("{}"))
+	})
+	mux.HandleFunc("/api/search", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("[]"))
+	})
+	mux.HandleFunc("/api/library-elements", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"result": {"totalCount": 0}}`))
+	})
+	mux.HandleFunc("/api/dashboards/uid/", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		w.Write([]byte("{}"))
+	})
+	mux.HandleFunc("/api/dashboards/db", func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		data := map[string]interface{}{}
+		json.Unmarshal(b, &data)
+		dashboard := data["dashboard"].(map[string]interface{})
+		requests = append(requests, fmt.Sprintf("POST %v to %v", dashboard["uid"], data["folderId"]))
+		w.Write([]byte("{}"))
+	})
+	startGrafanaServer(t, mux)
+
+	getConfigmap := func(folder string, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "moved",
+				Namespace:   "ns",
+				Labels:      map[string]string{"grafana-custom-dashboard": "true"},
+				Annotations: map[string]string{customFolderKey: folder},
+			},
+			Data: data,
+		}
+	}
+	old := getConfigmap("Old", map[string]string{
+		"a.json": `{"title": "A", "uid": "a"}`,
+		"b.json": `{"title": "B", "uid": "b"}`,
+		"c.json": `{"title": "C", "uid": "c"}`,
+	})
+	// the uid of a changed, b was removed and c moved to the new folder
+	new := getConfigmap("New", map[string]string{
+		"a.json": `{"title": "A", "uid": "a2"}`,
+		"c.json": `{"title": "C", "uid": "c"}`,
+	})
+
+	err := updateDashboard(old, new, false)
+	if err != nil {
+		t.Fatalf("fail to update dashboards with %v", err)
+	}
+	expected := []string{
+		"DELETE /api/dashboards/uid/a",
+		"DELETE /api/dashboards/uid/b",
+		"POST a2 to 2",
+		"POST c to 2",
+		"DELETE /api/folders/loader-old",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("the requests %v are not the expected %v", requests, expected)
+	}
+	forgetDashboardHash("a2")
+	forgetDashboardHash("c")
+}
+
+func TestIsDeletableFolder(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/folders", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[{"id": 1, "uid": "loader-empty", "title": "Empty"}, {"id": 2, "uid": "team", "title": "Team"},
+			{"id": 3, "uid": "loader-dashboards", "title": "Dashboards"}, {"id": 4, "uid": "loader-rules", "title": "Rules"},
+			{"id": 5, "uid": "loader-panels", "title": "Panels"}]`))
+	})
+	mux.HandleFunc("/api/search", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("folderIds") == "3" {
+			w.Write([]byte(`[{"uid": "dashboard"}]`))
+			return
+		}
+		w.Write([]byte("[]"))
+	})
+	mux.HandleFunc("/api/v1/provisioning/alert-rules", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[{"uid": "rule", "folderUID": "loader-rules"}]`))
+	})
+	mux.HandleFunc("/api/library-elements", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("folderFilter") == "5" {
+			w.Write([]byte(`{"result": {"totalCount": 1}}`))
+			return
+		}
+		w.Write([]byte(`{"result": {"totalCount": 0}}`))
+	})
+	startGrafanaServer(t, mux)
+
+	testCaseList := []struct {
+		name     string
+		folderID float64
+		expected bool
+	}{
+		{"general folder", 0, false},
+		{"missing folder", 9, false},
+		{"empty loader folder", 1, true},
+		{"folder created by a user", 2, false},
+		{"folder with dashboards", 3, false},
+		{"folder with alert rules", 4, false},
+		{"folder with library elements", 5, false},
+	}
+
+	for _, c := range testCaseList {
+		output := isDeletableFolder(c.folderID)
+		if output != c.expected {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
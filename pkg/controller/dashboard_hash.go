@@ -0,0 +1,124 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// dashboardHashTagPrefix prefixes the tag recording the hash of a loaded dashboard, so the
+// hash survives restarts of the loader
+const dashboardHashTagPrefix = "loader-hash:"
+
+var (
+	// dashboardHashes records the hash of each dashboard loaded by uid
+	dashboardHashes = map[string]string{}
+	hashMutex       sync.Mutex
+)
+
+// getDashboardHash returns the hash of the rendered dashboard and everything else deciding
+// how it is loaded, without the uid the hash is recorded by, which changes when a dashboard
+// is adopted
+func getDashboardHash(dashboard map[string]interface{}, folderTitle string, homeScopes []string) string {
+	hashed := map[string]interface{}{}
+	for k, v := range dashboard {
+		if k != "uid" {
+			hashed[k] = v
+		}
+	}
+	// json sorts the keys of maps
+	b, err := json.Marshal(map[string]interface{}{
+		"dashboard": hashed,
+		"folder":    folderTitle,
+		"home":      homeScopes,
+	})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// getDashboardHashTag returns the hash of the dashboard's hash tag
+func getDashboardHashTag(dashboard map[string]interface{}) string {
+	tags, _ := dashboard["tags"].([]interface{})
+	for _, tag := range tags {
+		if s, ok := tag.(string); ok && strings.HasPrefix(s, dashboardHashTagPrefix) {
+			return strings.TrimPrefix(s, dashboardHashTagPrefix)
+		}
+	}
+	return ""
+}
+
+// setDashboardHashTag returns a copy of the dashboard tagged with the hash, without other hash tags
+func setDashboardHashTag(dashboard map[string]interface{}, hash string) map[string]interface{} {
+	tagged := removeDashboardHashTag(dashboard)
+	tags, _ := tagged["tags"].([]interface{})
+	tagged["tags"] = append(tags, dashboardHashTagPrefix+hash)
+	return tagged
+}
+
+// removeDashboardHashTag returns a copy of the dashboard without the hash tag, and without
+// tags when no other tag is left
+func removeDashboardHashTag(dashboard map[string]interface{}) map[string]interface{} {
+	copied := map[string]interface{}{}
+	for k, v := range dashboard {
+		copied[k] = v
+	}
+	tags, ok := dashboard["tags"].([]interface{})
+	if !ok {
+		return copied
+	}
+
+	kept := []interface{}{}
+	for _, tag := range tags {
+		if s, ok := tag.(string); ok && strings.HasPrefix(s, dashboardHashTagPrefix) {
+			continue
+		}
+		kept = append(kept, tag)
+	}
+	if len(kept) == 0 {
+		delete(copied, "tags")
+	} else {
+		copied["tags"] = kept
+	}
+	return copied
+}
+
+// isDashboardUnchanged returns whether the dashboard with the hash is loaded already, the
+// hash tag of the dashboard in grafana is checked when the loader has not loaded it yet
+func isDashboardUnchanged(uid, hash string) bool {
+	if hash == "" {
+		return false
+	}
+	hashMutex.Lock()
+	loaded, found := dashboardHashes[uid]
+	hashMutex.Unlock()
+	if found {
+		return loaded == hash
+	}
+
+	live, _, found := getLiveDashboard(uid)
+	if !found || getDashboardHashTag(live) != hash {
+		return false
+	}
+	recordDashboardHash(uid, hash)
+	return true
+}
+
+func recordDashboardHash(uid, hash string) {
+	hashMutex.Lock()
+	defer hashMutex.Unlock()
+	dashboardHashes[uid] = hash
+}
+
+func forgetDashboardHash(uid string) {
+	hashMutex.Lock()
+	defer hashMutex.Unlock()
+	delete(dashboardHashes, uid)
+}
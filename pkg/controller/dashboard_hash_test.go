@@ -0,0 +1,72 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGetDashboardHash(t *testing.T) {
+	dashboard := map[string]interface{}{"uid": "a", "title": "A", "tags": []interface{}{"x"}}
+	hash := getDashboardHash(dashboard, "Custom", nil)
+	if len(hash) != 16 {
+		t.Errorf("the hash %v is not the expected 16 hex digits", hash)
+	}
+
+	reordered := map[string]interface{}{"tags": []interface{}{"x"}, "title": "A", "uid": "a"}
+	if getDashboardHash(reordered, "Custom", nil) != hash {
+		t.Errorf("the hash should not depend on the order of the fields")
+	}
+	if getDashboardHash(dashboard, "Other", nil) == hash {
+		t.Errorf("the hash should change with the folder")
+	}
+	if getDashboardHash(dashboard, "Custom", []string{orgScope}) == hash {
+		t.Errorf("the hash should change with the home dashboard scopes")
+	}
+}
+
+func TestDashboardHashTag(t *testing.T) {
+	dashboard := map[string]interface{}{"tags": []interface{}{"x", dashboardHashTagPrefix + "old"}}
+
+	tagged := setDashboardHashTag(dashboard, "new")
+	if !reflect.DeepEqual(tagged["tags"], []interface{}{"x", dashboardHashTagPrefix + "new"}) || getDashboardHashTag(tagged) != "new" {
+		t.Errorf("the tags %v are not the expected", tagged["tags"])
+	}
+	if getDashboardHashTag(dashboard) != "old" {
+		t.Errorf("the original dashboard should not be changed")
+	}
+
+	untagged := removeDashboardHashTag(map[string]interface{}{"tags": []interface{}{dashboardHashTagPrefix + "old"}})
+	if _, ok := untagged["tags"]; ok {
+		t.Errorf("the tags %v should be removed without other tags", untagged["tags"])
+	}
+}
+
+func TestIsDashboardUnchanged(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/dashboards/uid/tagged", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"dashboard": {"uid": "tagged", "tags": ["` + dashboardHashTagPrefix + `abc"]}, "meta": {}}`))
+	})
+	startGrafanaServer(t, mux)
+	defer forgetDashboardHash("tagged")
+	defer forgetDashboardHash("recorded")
+
+	if !isDashboardUnchanged("tagged", "abc") || isDashboardUnchanged("tagged", "def") {
+		t.Errorf("the hash tag of the live dashboard should be compared")
+	}
+	if isDashboardUnchanged("missing", "abc") {
+		t.Errorf("a missing dashboard should not be unchanged")
+	}
+
+	recordDashboardHash("recorded", "abc")
+	if !isDashboardUnchanged("recorded", "abc") || isDashboardUnchanged("recorded", "def") {
+		t.Errorf("the recorded hash should be compared")
+	}
+	forgetDashboardHash("recorded")
+	if isDashboardUnchanged("recorded", "abc") {
+		t.Errorf("the forgotten hash should not be compared")
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+const (
+	datasourceLabel = "grafana-custom-datasource"
+	// datasourceSecretKey names the secret in the configmap's namespace whose keys
+	// are set as secureJsonData of the datasources
+	datasourceSecretKey = "observability.open-cluster-management.io/datasource-secret"
+)
+
+var (
+	// DatasourceSecretResyncPeriod is how often the secrets of the datasource configmaps are
+	// read again, so that rotated credentials are loaded
+	DatasourceSecretResyncPeriod = 5 * time.Minute
+
+	// datasourceSecretHashes records the hash of the secret data last loaded by configmap
+	datasourceSecretHashes = map[string]string{}
+	datasourceSecretMutex  sync.Mutex
+)
+
+func isDatasourceConfigmap(obj interface{}) bool {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return false
+	}
+
+	return strings.ToLower(cm.ObjectMeta.Labels[datasourceLabel]) == "true"
+}
+
+// getDatasources parses the yaml or json datasources of the configmap by uid,
+// generated from the configmap and data key if the datasource does not set one
+func getDatasources(obj interface{}) map[string]map[string]interface{} {
+	datasources := map[string]map[string]interface{}{}
+	for _, datasource := range getDatasourcesByKey(obj) {
+		datasources[datasource["uid"].(string)] = datasource
+	}
+	return datasources
+}
+
+// getDatasourcesByKey parses the yaml or json datasources of the configmap by data key
+func getDatasourcesByKey(obj interface{}) map[string]map[string]interface{} {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return nil
+	}
+
+	datasources := map[string]map[string]interface{}{}
+	for key, value := range cm.Data {
+		datasource := map[string]interface{}{}
+		err := yaml.Unmarshal([]byte(value), &datasource)
+		if err != nil {
+			klog.Errorf("Failed to unmarshall data %v: %v", key, err)
+			continue
+		}
+
+		uid, ok := datasource["uid"].(string)
+		if !ok || uid == "" {
+			uid = generateResourceUID(cm, getDatasourceName(key))
+		}
+		datasource["uid"] = uid
+		delete(datasource, "id")
+		datasources[key] = datasource
+	}
+	return datasources
+}
+
+// getDatasourceName returns the name of the datasource of the data key, the key without extension
+func getDatasourceName(key string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(key, ".json"), ".yaml")
+}
+
+// getDatasourceSecureData returns the credentials of the configmap's datasources by datasource
+// name, the secret key <name>.<field> sets the field of the datasource of the data key <name>,
+// the keys without a name are returned for the name ""
+func getDatasourceSecureData(coreClient corev1client.CoreV1Interface, cm *corev1.ConfigMap) (map[string]map[string]interface{}, bool) {
+	secretName := cm.ObjectMeta.Annotations[datasourceSecretKey]
+	if secretName == "" {
+		return nil, true
+	}
+	if coreClient == nil {
+		klog.Errorf("Failed to get datasource secret %v without kubernetes", secretName)
+		return nil, false
+	}
+
+	secret, err := coreClient.Secrets(cm.GetNamespace()).Get(context.TODO(), secretName, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Failed to get datasource secret %v: %v", secretName, err)
+		return nil, false
+	}
+
+	secureData := map[string]map[string]interface{}{}
+	for key, value := range secret.Data {
+		name, field := "", key
+		if i := strings.LastIndex(key, "."); i >= 0 {
+			name, field = key[:i], key[i+1:]
+		}
+		if secureData[name] == nil {
+			secureData[name] = map[string]interface{}{}
+		}
+		secureData[name][field] = string(value)
+	}
+	return secureData, true
+}
+
+// getDatasourceSecureJSONData returns the credentials of the datasource of the data key, the
+// keys without a name are only set when the configmap holds a single datasource
+func getDatasourceSecureJSONData(secureData map[string]map[string]interface{}, key string, count int) map[string]interface{} {
+	secureJSONData := map[string]interface{}{}
+	if count == 1 {
+		for field, value := range secureData[""] {
+			secureJSONData[field] = value
+		}
+	}
+	for field, value := range secureData[getDatasourceName(key)] {
+		secureJSONData[field] = value
+	}
+	return secureJSONData
+}
+
+// hashDatasourceSecureData returns the hash of the credentials to detect rotated secrets
+func hashDatasourceSecureData(secureData map[string]map[string]interface{}) string {
+	b, _ := json.Marshal(secureData)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// updateDatasources is used to create or update the datasources via calling grafana api
+func updateDatasources(coreClient corev1client.CoreV1Interface, old, new interface{}) {
+	cm := new.(*corev1.ConfigMap)
+	secureData, ok := getDatasourceSecureData(coreClient, cm)
+	if !ok {
+		return
+	}
+	if len(secureData[""]) > 0 && len(cm.Data) > 1 {
+		klog.Warningf("the keys of datasource secret of %v without a datasource name are ignored", getConfigmapName(cm))
+	}
+
+	loaded := true
+	datasources := map[string]map[string]interface{}{}
+	for key, datasource := range getDatasourcesByKey(new) {
+		uid := datasource["uid"].(string)
+		datasources[uid] = datasource
+		if secureJSONData := getDatasourceSecureJSONData(secureData, key, len(cm.Data)); len(secureJSONData) > 0 {
+			existing, _ := datasource["secureJsonData"].(map[string]interface{})
+			for field, value := range existing {
+				if _, ok := secureJSONData[field]; !ok {
+					secureJSONData[field] = value
+				}
+			}
+			datasource["secureJsonData"] = secureJSONData
+		}
+
+		b, err := json.Marshal(datasource)
+		if err != nil {
+			klog.Error("failed to marshal body", "error", err)
+			continue
+		}
+
+		method := "POST"
+		grafanaURL := grafanaURI + "/api/datasources"
+		if hasDatasource(uid) {
+			method = "PUT"
+			grafanaURL = grafanaURL + "/uid/" + uid
+		}
+		_, respStatusCode := util.SetRequest(method, grafanaURL, bytes.NewBuffer(b), retry)
+		if respStatusCode != http.StatusOK {
+			klog.Infof("failed to create/update datasource %v: %v", uid, respStatusCode)
+			loaded = false
+			continue
+		}
+		klog.Infof("Datasource %v created/updated", uid)
+	}
+	if loaded && cm.ObjectMeta.Annotations[datasourceSecretKey] != "" {
+		datasourceSecretMutex.Lock()
+		datasourceSecretHashes[getConfigmapName(cm)] = hashDatasourceSecureData(secureData)
+		datasourceSecretMutex.Unlock()
+	}
+
+	// delete the datasources removed from the configmap
+	for uid := range getDatasources(old) {
+		if _, ok := datasources[uid]; !ok {
+			deleteDatasource(uid)
+		}
+	}
+}
+
+// deleteDatasources is used to delete the datasources of the configmap via calling grafana api
+func deleteDatasources(obj interface{}) {
+	for uid := range getDatasources(obj) {
+		deleteDatasource(uid)
+	}
+	if cm, ok := obj.(*corev1.ConfigMap); ok && cm != nil {
+		datasourceSecretMutex.Lock()
+		delete(datasourceSecretHashes, getConfigmapName(cm))
+		datasourceSecretMutex.Unlock()
+	}
+}
+
+func hasDatasource(uid string) bool {
+	grafanaURL := grafanaURI + "/api/datasources/uid/" + uid
+	_, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	return respStatusCode == http.StatusOK
+}
+
+func deleteDatasource(uid string) {
+	grafanaURL := grafanaURI + "/api/datasources/uid/" + uid
+	_, respStatusCode := util.SetRequest("DELETE", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK && respStatusCode != http.StatusNotFound {
+		klog.Errorf("failed to delete datasource %v with %v", uid, respStatusCode)
+		return
+	}
+	klog.Infof("Datasource %v deleted", uid)
+}
+
+// syncDatasources loads the datasources before the dashboards so that the
+// dashboards referencing them resolve on first load
+func syncDatasources(coreClient corev1client.CoreV1Interface) {
+	watchedNS := os.Getenv("POD_NAMESPACE")
+	cms, err := coreClient.ConfigMaps(watchedNS).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		klog.Error("Failed to list datasource configmaps", "error", err)
+		return
+	}
+
+	for i := range cms.Items {
+		if isDatasourceConfigmap(&cms.Items[i]) {
+			updateDatasources(coreClient, nil, &cms.Items[i])
+		}
+	}
+}
+
+// resyncDatasourceSecrets queues the datasource configmaps whose secret changed since their
+// datasources were loaded, the secrets are not watched
+func resyncDatasourceSecrets(coreClient corev1client.CoreV1Interface, queue *configmapQueue) {
+	watchedNS := os.Getenv("POD_NAMESPACE")
+	cms, err := coreClient.ConfigMaps(watchedNS).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		klog.Error("Failed to list datasource configmaps", "error", err)
+		return
+	}
+
+	for i := range cms.Items {
+		cm := &cms.Items[i]
+		if !isDatasourceConfigmap(cm) || cm.ObjectMeta.Annotations[datasourceSecretKey] == "" {
+			continue
+		}
+		datasourceSecretMutex.Lock()
+		loaded, ok := datasourceSecretHashes[getConfigmapName(cm)]
+		datasourceSecretMutex.Unlock()
+		if !ok {
+			// not loaded yet, or failed to load
+			continue
+		}
+		secureData, ok := getDatasourceSecureData(coreClient, cm)
+		if !ok || hashDatasourceSecureData(secureData) == loaded {
+			continue
+		}
+		klog.Infof("the datasource secret of %v changed, load the datasources again", getConfigmapName(cm))
+		queue.add(cm, cm)
+	}
+}
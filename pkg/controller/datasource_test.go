@@ -0,0 +1,163 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetDatasources(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "datasources",
+			Namespace: "ns",
+			Labels:    map[string]string{datasourceLabel: "true"},
+		},
+		Data: map[string]string{
+			"thanos.yaml":  "name: Thanos\ntype: prometheus\nurl: http://thanos:9090\n",
+			"loki.json":    `{"id": 3, "uid": "loki", "name": "Loki", "type": "loki"}`,
+			"invalid.json": "{",
+		},
+	}
+
+	if !isDatasourceConfigmap(cm) {
+		t.Errorf("the configmap should be a datasource configmap")
+	}
+
+	datasources := getDatasources(cm)
+	if len(datasources) != 2 {
+		t.Fatalf("the datasources %v are not the expected 2", datasources)
+	}
+	if datasources["datasources-thanos-ns"]["url"] != "http://thanos:9090" {
+		t.Errorf("the datasource %v is not the expected thanos", datasources["datasources-thanos-ns"])
+	}
+	if _, ok := datasources["loki"]["id"]; ok {
+		t.Errorf("the datasource id should be removed")
+	}
+}
+
+func TestGetDatasourceSecureData(t *testing.T) {
+	coreClient := fake.NewSimpleClientset().CoreV1()
+	_, err := coreClient.Secrets("ns").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "ns"},
+		Data:       map[string][]byte{"basicAuthPassword": []byte("secret"), "thanos.password": []byte("thanos")},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("fail to create secret with %v", err)
+	}
+
+	testCaseList := []struct {
+		name     string
+		secret   string
+		expected interface{}
+		ok       bool
+	}{
+		{"no secret", "", nil, true},
+		{"missing secret", "missing", nil, false},
+		{"valid secret", "credentials", "secret", true},
+	}
+
+	for _, c := range testCaseList {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "datasources",
+				Namespace:   "ns",
+				Annotations: map[string]string{datasourceSecretKey: c.secret},
+			},
+		}
+		output, ok := getDatasourceSecureData(coreClient, cm)
+		if ok != c.ok || output[""]["basicAuthPassword"] != c.expected {
+			t.Errorf("case (%v) output: (%v, %v) is not the expected: (%v, %v)", c.name, output, ok, c.expected, c.ok)
+		}
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{datasourceSecretKey: "credentials"}},
+	}
+	if _, ok := getDatasourceSecureData(nil, cm); ok {
+		t.Errorf("the secret should not be read without kubernetes")
+	}
+}
+
+func TestGetDatasourceSecureJSONData(t *testing.T) {
+	secureData := map[string]map[string]interface{}{
+		"":       {"basicAuthPassword": "shared"},
+		"thanos": {"password": "thanos", "basicAuthPassword": "thanos"},
+	}
+
+	testCaseList := []struct {
+		name     string
+		key      string
+		count    int
+		expected map[string]interface{}
+	}{
+		{"single datasource", "loki.yaml", 1, map[string]interface{}{"basicAuthPassword": "shared"}},
+		{"named datasource", "thanos.yaml", 2, map[string]interface{}{"password": "thanos", "basicAuthPassword": "thanos"}},
+		{"other datasource", "loki.yaml", 2, map[string]interface{}{}},
+	}
+
+	for _, c := range testCaseList {
+		output := getDatasourceSecureJSONData(secureData, c.key, c.count)
+		if !reflect.DeepEqual(output, c.expected) {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
+
+func TestResyncDatasourceSecrets(t *testing.T) {
+	os.Setenv("POD_NAMESPACE", "ns")
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "datasources",
+			Namespace:   "ns",
+			Labels:      map[string]string{datasourceLabel: "true"},
+			Annotations: map[string]string{datasourceSecretKey: "credentials"},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "ns"},
+		Data:       map[string][]byte{"password": []byte("old")},
+	}
+	coreClient := fake.NewSimpleClientset(cm, secret).CoreV1()
+	queue := newConfigmapQueue(getConfigmapHandlers(coreClient))
+	defer queue.queue.ShutDown()
+	defer func() { datasourceSecretHashes = map[string]string{} }()
+
+	// the configmaps not loaded yet are left to the informer
+	resyncDatasourceSecrets(coreClient, queue)
+	if queue.queue.Len() != 0 {
+		t.Errorf("the configmap should not be queued before it is loaded")
+	}
+
+	secureData, _ := getDatasourceSecureData(coreClient, cm)
+	datasourceSecretHashes["ns/datasources"] = hashDatasourceSecureData(secureData)
+	resyncDatasourceSecrets(coreClient, queue)
+	if queue.queue.Len() != 0 {
+		t.Errorf("the configmap should not be queued when the secret did not change")
+	}
+
+	secret.Data["password"] = []byte("rotated")
+	coreClient.Secrets("ns").Update(context.TODO(), secret, metav1.UpdateOptions{})
+	resyncDatasourceSecrets(coreClient, queue)
+	if queue.queue.Len() != 1 {
+		t.Errorf("the configmap should be queued when the secret changed")
+	}
+}
+
+func TestHasDatasource(t *testing.T) {
+	startFakeServer(t)
+
+	if !hasDatasource("existing") {
+		t.Errorf("the datasource existing should exist")
+	}
+	if hasDatasource("missing") {
+		t.Errorf("the datasource missing should not exist")
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+const (
+	// ChangeCreate marks dashboards or folders which would be created
+	ChangeCreate = "create"
+	// ChangeUpdate marks dashboards which would be updated
+	ChangeUpdate = "update"
+	// ChangeDelete marks folders which would be deleted
+	ChangeDelete = "delete"
+	// ChangeNone marks dashboards which are up to date
+	ChangeNone = "unchanged"
+)
+
+// volatileDashboardFields are set by grafana and ignored when comparing dashboards
+var volatileDashboardFields = []string{"id", "version", "iteration"}
+
+// JSONChange is a difference between the live and the desired value at a JSON path,
+// the op is add, remove or replace as in JSON patches
+type JSONChange struct {
+	Op      string      `json:"op"`
+	Path    string      `json:"path"`
+	Live    interface{} `json:"live,omitempty"`
+	Desired interface{} `json:"desired,omitempty"`
+}
+
+// DashboardDiff is the planned change of a dashboard
+type DashboardDiff struct {
+	ConfigMap  string       `json:"configmap"`
+	Key        string       `json:"key"`
+	UID        string       `json:"uid"`
+	Title      string       `json:"title"`
+	Action     string       `json:"action"`
+	LiveFolder string       `json:"liveFolder,omitempty"`
+	Folder     string       `json:"folder"`
+	Changes    []JSONChange `json:"changes,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// FolderDiff is the planned change of a folder
+type FolderDiff struct {
+	Title  string `json:"title"`
+	Action string `json:"action"`
+}
+
+// DiffReport is the plan of changes to bring grafana to the dashboards of the configmaps
+type DiffReport struct {
+	Dashboards []DashboardDiff `json:"dashboards"`
+	Folders    []FolderDiff    `json:"folders"`
+}
+
+// HasChanges returns whether any dashboard or folder would change, the dashboards failing to
+// be compared are not counted
+func (r *DiffReport) HasChanges() bool {
+	if len(r.Folders) > 0 {
+		return true
+	}
+	for _, d := range r.Dashboards {
+		if d.Error == "" && d.Action != ChangeNone {
+			return true
+		}
+	}
+	return false
+}
+
+// HasErrors returns whether any dashboard failed to be compared
+func (r *DiffReport) HasErrors() bool {
+	for _, d := range r.Dashboards {
+		if d.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffOptions selects the configmaps to compare with grafana
+type DiffOptions struct {
+	GrafanaURL string
+	// Files are manifest files or directories, used when Namespace is empty
+	Files      []string
+	Namespace  string
+	Kubeconfig string
+}
+
+// DiffConfigmaps compares the dashboards of the configmaps with the dashboards in grafana
+func DiffConfigmaps(opts DiffOptions) (*DiffReport, error) {
+	if opts.GrafanaURL != "" {
+		grafanaURI = opts.GrafanaURL
+	}
+	files, err := loadConfigmaps(opts.Files, opts.Namespace, opts.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DiffReport{Dashboards: []DashboardDiff{}, Folders: []FolderDiff{}}
+	existingFolders, err := getFolderTitles()
+	if err != nil {
+		return nil, err
+	}
+	plannedFolders := map[string]bool{}
+	// leaving counts the dashboards which would leave each live folder
+	leaving := map[string]int{}
+	for _, file := range files {
+		cm := file.cm
+		if !isDesiredDashboardConfigmap(cm) {
+			continue
+		}
+		folder := getDashboardCustomFolderTitle(cm)
+		if folder != "" && !existingFolders[folder] && !plannedFolders[folder] {
+			plannedFolders[folder] = true
+			report.Folders = append(report.Folders, FolderDiff{Title: folder, Action: ChangeCreate})
+		}
+
+		for _, key := range getDashboardKeys(cm) {
+			d := DashboardDiff{ConfigMap: getConfigmapName(cm), Key: key, Folder: folder}
+			dashboard, err := parseDashboard(cm, key)
+			if err != nil {
+				d.Error = err.Error()
+				report.Dashboards = append(report.Dashboards, d)
+				continue
+			}
+			d.UID = dashboard["uid"].(string)
+			d.Title, _ = dashboard["title"].(string)
+
+			live, meta, err := lookupLiveDashboard(d.UID)
+			if err != nil {
+				d.Error = err.Error()
+				report.Dashboards = append(report.Dashboards, d)
+				continue
+			}
+			if live == nil {
+				d.Action = ChangeCreate
+				report.Dashboards = append(report.Dashboards, d)
+				continue
+			}
+
+			liveFolder := getLiveDashboardFolder(meta)
+			d.LiveFolder = liveFolder
+			d.Changes = diffJSON("", normalizeDashboard(live), normalizeDashboard(dashboard))
+			d.Action = ChangeNone
+			if len(d.Changes) > 0 || liveFolder != folder {
+				d.Action = ChangeUpdate
+			}
+			if liveFolder != folder && liveFolder != "" {
+				leaving[liveFolder]++
+			}
+			report.Dashboards = append(report.Dashboards, d)
+		}
+	}
+
+	titles := []string{}
+	for title := range leaving {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+	for _, title := range titles {
+		// only the folders created by the loader without alert rules or library elements are deleted
+		folder, ok := folders.getByTitle(title)
+		if !ok || !folder.isLoaderFolder() || countFolderDashboards(folder.id) != leaving[title] {
+			continue
+		}
+		if !hasFolderAlertRules(folder.uid) && !hasFolderLibraryElements(folder.id) {
+			report.Folders = append(report.Folders, FolderDiff{Title: title, Action: ChangeDelete})
+		}
+	}
+	return report, nil
+}
+
+// getLiveDashboard returns the dashboard with the uid in grafana and the title of its folder
+func getLiveDashboard(uid string) (map[string]interface{}, string, bool) {
+	dashboard, meta, found := getLiveDashboardWithMeta(uid)
+	if !found {
+		return nil, "", false
+	}
+	return dashboard, getLiveDashboardFolder(meta), true
+}
+
+// getLiveDashboardFolder returns the folder title of the dashboard metadata, empty for the
+// general folder
+func getLiveDashboardFolder(meta map[string]interface{}) string {
+	folder, _ := meta["folderTitle"].(string)
+	if folderID, _ := meta["folderId"].(float64); folderID == 0 {
+		// dashboards in the general folder
+		folder = ""
+	}
+	return folder
+}
+
+// getLiveDashboardWithMeta returns the dashboard in grafana with its metadata, e.g. updatedBy
+func getLiveDashboardWithMeta(uid string) (map[string]interface{}, map[string]interface{}, bool) {
+	dashboard, meta, err := lookupLiveDashboard(uid)
+	if err != nil || dashboard == nil {
+		return nil, nil, false
+	}
+	return dashboard, meta, true
+}
+
+// lookupLiveDashboard returns the dashboard in grafana with its metadata, the dashboard is nil
+// when grafana does not find it, other failures are returned as errors
+func lookupLiveDashboard(uid string) (map[string]interface{}, map[string]interface{}, error) {
+	grafanaURL := grafanaURI + "/api/dashboards/uid/" + uid
+	body, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	if respStatusCode == http.StatusNotFound {
+		return nil, nil, nil
+	}
+	if respStatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("failed to get dashboard %v from grafana with %v", uid, respStatusCode)
+	}
+
+	result := struct {
+		Dashboard map[string]interface{} `json:"dashboard"`
+		Meta      map[string]interface{} `json:"meta"`
+	}{}
+	err := json.Unmarshal(body, &result)
+	if err != nil || result.Dashboard == nil {
+		return nil, nil, fmt.Errorf("failed to read dashboard %v from grafana: %v", uid, err)
+	}
+	return result.Dashboard, result.Meta, nil
+}
+
+// getFolderTitles returns the titles of the folders in grafana
+func getFolderTitles() (map[string]bool, error) {
+	titles := map[string]bool{}
+	if !folders.refresh() {
+		return nil, fmt.Errorf("failed to get the folders from grafana")
+	}
+
+	folders.mutex.Lock()
+	defer folders.mutex.Unlock()
+	for title := range folders.byTitle {
+		titles[title] = true
+	}
+	return titles, nil
+}
+
+// countFolderDashboards returns the number of dashboards in the folder, or -1 if unknown
+func countFolderDashboards(folderID float64) int {
+	if folderID == 0 {
+		return -1
+	}
+
+	grafanaURL := grafanaURI + "/api/search?folderIds=" + fmt.Sprint(folderID)
+	body, _ := util.SetRequest("GET", grafanaURL, nil, retry)
+	dashboards := []map[string]interface{}{}
+	err := json.Unmarshal(body, &dashboards)
+	if err != nil {
+		return -1
+	}
+	return len(dashboards)
+}
+
+// normalizeDashboard returns a copy of the dashboard without volatile fields and the hash tag
+func normalizeDashboard(dashboard map[string]interface{}) map[string]interface{} {
+	normalized := removeDashboardHashTag(dashboard)
+	for _, field := range volatileDashboardFields {
+		delete(normalized, field)
+	}
+	return normalized
+}
+
+// diffJSON returns the differences between two decoded JSON values
+func diffJSON(path string, live, desired interface{}) []JSONChange {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := []string{}
+		for k := range d {
+			keys = append(keys, k)
+		}
+		for k := range l {
+			if _, ok := d[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		changes := []JSONChange{}
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			lv, inLive := l[k]
+			dv, inDesired := d[k]
+			if !inLive {
+				changes = append(changes, JSONChange{Op: "add", Path: p, Desired: dv})
+			} else if !inDesired {
+				changes = append(changes, JSONChange{Op: "remove", Path: p, Live: lv})
+			} else {
+				changes = append(changes, diffJSON(p, lv, dv)...)
+			}
+		}
+		return changes
+
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok {
+			break
+		}
+		changes := []JSONChange{}
+		for i := 0; i < len(d) || i < len(l); i++ {
+			p := fmt.Sprintf("%v[%v]", path, i)
+			if i >= len(l) {
+				changes = append(changes, JSONChange{Op: "add", Path: p, Desired: d[i]})
+			} else if i >= len(d) {
+				changes = append(changes, JSONChange{Op: "remove", Path: p, Live: l[i]})
+			} else {
+				changes = append(changes, diffJSON(p, l[i], d[i])...)
+			}
+		}
+		return changes
+	}
+
+	if reflect.DeepEqual(live, desired) {
+		return nil
+	}
+	return []JSONChange{{Op: "replace", Path: path, Live: live, Desired: desired}}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestDiffJSON(t *testing.T) {
+	live := map[string]interface{}{}
+	desired := map[string]interface{}{}
+	json.Unmarshal([]byte(`{"title": "A", "tags": ["a"], "removed": true, "panels": [{"id": 1, "title": "x"}]}`), &live)
+	json.Unmarshal([]byte(`{"title": "B", "tags": ["a", "b"], "panels": [{"id": 1, "title": "x"}], "added": 1}`), &desired)
+
+	expected := []JSONChange{
+		{Op: "add", Path: "added", Desired: float64(1)},
+		{Op: "remove", Path: "removed", Live: true},
+		{Op: "add", Path: "tags[1]", Desired: "b"},
+		{Op: "replace", Path: "title", Live: "A", Desired: "B"},
+	}
+	changes := diffJSON("", live, desired)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("the changes %v are not the expected %v", changes, expected)
+	}
+
+	if len(diffJSON("", live, live)) != 0 {
+		t.Errorf("there should be no changes between the same values")
+	}
+}
+
+func TestNormalizeDashboard(t *testing.T) {
+	dashboard := map[string]interface{}{"id": 1, "version": 2, "iteration": 3, "title": "A"}
+	normalized := normalizeDashboard(dashboard)
+	if !reflect.DeepEqual(normalized, map[string]interface{}{"title": "A"}) {
+		t.Errorf("the dashboard %v is not normalized", normalized)
+	}
+	if len(dashboard) != 4 {
+		t.Errorf("the original dashboard should not be changed")
+	}
+}
+
+func TestDiffConfigmaps(t *testing.T) {
+	startFakeServer(t)
+
+	report, err := DiffConfigmaps(DiffOptions{Files: []string{"../../examples/k8s-dashboard.yaml"}})
+	if err != nil {
+		t.Fatalf("fail to diff configmaps with %v", err)
+	}
+	if len(report.Dashboards) != 1 || len(report.Folders) != 0 {
+		t.Fatalf("the report %+v is not the expected", report)
+	}
+
+	d := report.Dashboards[0]
+	if d.Action != ChangeUpdate || d.Folder != "Custom" || d.LiveFolder != "Custom" {
+		t.Errorf("the dashboard diff %+v is not the expected", d)
+	}
+	for _, change := range d.Changes {
+		if change.Path == "id" || change.Path == "version" || change.Path == "iteration" {
+			t.Errorf("the volatile field %v should be ignored", change.Path)
+		}
+	}
+	if !report.HasChanges() {
+		t.Errorf("the report should have changes")
+	}
+}
+
+func TestDiffConfigmapsErrors(t *testing.T) {
+	foldersStatus := http.StatusOK
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/folders", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(foldersStatus)
+		w.Write([]byte(`[{"id": 1, "uid": "custom", "title": "Custom"}]`))
+	})
+	mux.HandleFunc("/api/dashboards/uid/", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	startGrafanaServer(t, mux)
+
+	// the dashboards which cannot be read are not planned to be created
+	report, err := DiffConfigmaps(DiffOptions{Files: []string{"../../examples/k8s-dashboard.yaml"}})
+	if err != nil {
+		t.Fatalf("fail to diff configmaps with %v", err)
+	}
+	if len(report.Dashboards) != 1 || report.Dashboards[0].Error == "" || report.Dashboards[0].Action != "" {
+		t.Errorf("the report %+v should have the error", report)
+	}
+	if !report.HasErrors() || report.HasChanges() {
+		t.Errorf("the report should have errors without changes")
+	}
+
+	foldersStatus = http.StatusForbidden
+	_, err = DiffConfigmaps(DiffOptions{Files: []string{"../../examples/k8s-dashboard.yaml"}})
+	if err == nil {
+		t.Errorf("the diff should fail without the folders")
+	}
+}
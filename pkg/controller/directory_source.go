@@ -0,0 +1,335 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// directoryNamespace is the namespace of the configmaps made from dashboard json files
+const directoryNamespace = "local"
+
+// directorySource loads the dashboard json files and configmap manifests of a directory
+// through the configmap queue, as the configmaps of the cluster
+type directorySource struct {
+	dir   string
+	queue *configmapQueue
+	// files records the configmaps loaded from each file, written with the mutex held
+	files map[string][]*corev1.ConfigMap
+	mutex sync.RWMutex
+}
+
+// RunDirectoryLoader loads the dashboards of the directory and keeps them in sync with
+// its files until stopped, without any kubernetes api
+func RunDirectoryLoader(dir string, stop <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	queue := newConfigmapQueue(getConfigmapHandlers(nil))
+	go queue.run(Workers, stop)
+	s := newDirectorySource(dir, queue)
+	addConfigmapLookup(s.lookup)
+	err = s.resync(watcher)
+	if err != nil {
+		return err
+	}
+	klog.Infof("watching dashboards in directory %v", dir)
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			s.handleEvent(watcher, event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			klog.Errorf("failed to watch directory %v: %v", dir, err)
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+func newDirectorySource(dir string, queue *configmapQueue) *directorySource {
+	return &directorySource{
+		dir:   filepath.Clean(dir),
+		queue: queue,
+		files: map[string][]*corev1.ConfigMap{},
+	}
+}
+
+// lookup returns the configmap loaded from the files of the directory
+func (s *directorySource) lookup(namespace, name string) *corev1.ConfigMap {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, cms := range s.files {
+		for _, cm := range cms {
+			if cm.GetNamespace() == namespace && cm.GetName() == name {
+				return cm
+			}
+		}
+	}
+	return nil
+}
+
+// isHiddenPath returns whether any part of the path below the directory starts with a dot,
+// e.g. editor swap files or the ..data links of mounted configmaps
+func (s *directorySource) isHiddenPath(path string) bool {
+	rel, err := filepath.Rel(s.dir, path)
+	if err != nil {
+		return true
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if strings.HasPrefix(part, ".") && part != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// getFolder returns the folder of the file, the name of its top subdirectory, or empty
+// for the files of the directory itself
+func (s *directorySource) getFolder(path string) string {
+	rel, err := filepath.Rel(s.dir, path)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0]
+}
+
+// readFile returns the configmaps of the file, a dashboard json file is wrapped into a
+// dashboard configmap
+func (s *directorySource) readFile(path string) ([]*corev1.ConfigMap, error) {
+	folder := s.getFolder(path)
+	files, err := readConfigmapFile(path)
+	if err != nil && strings.ToLower(filepath.Ext(path)) != ".json" {
+		return nil, err
+	}
+
+	cms := []*corev1.ConfigMap{}
+	for _, file := range files {
+		cm := file.cm
+		_, hasFolder := cm.ObjectMeta.Annotations[customFolderKey]
+		if folder != "" && !hasFolder && cm.ObjectMeta.Labels[generalFolderKey] == "" {
+			if cm.ObjectMeta.Annotations == nil {
+				cm.ObjectMeta.Annotations = map[string]string{}
+			}
+			cm.ObjectMeta.Annotations[customFolderKey] = folder
+		}
+		cms = append(cms, cm)
+	}
+	if len(cms) > 0 || strings.ToLower(filepath.Ext(path)) != ".json" {
+		return cms, nil
+	}
+
+	// the json file is a dashboard
+	rel, _ := filepath.Rel(s.dir, path)
+	cm, err := readDashboardFile(path, rel, folder)
+	if err != nil {
+		return nil, err
+	}
+	return []*corev1.ConfigMap{cm}, nil
+}
+
+// readDashboardFile wraps the dashboard json file into a dashboard configmap named after its
+// relative path, in the folder or the general folder
+func readDashboardFile(path, rel, folder string) (*corev1.ConfigMap, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dashboard := map[string]interface{}{}
+	err = json.Unmarshal(data, &dashboard)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(rel), "-"), "-"),
+			Namespace: directoryNamespace,
+			Labels:    map[string]string{"grafana-custom-dashboard": "true"},
+		},
+		Data: map[string]string{filepath.Base(path): string(data)},
+	}
+	if folder == "" {
+		cm.ObjectMeta.Labels[generalFolderKey] = "true"
+	} else {
+		cm.ObjectMeta.Annotations = map[string]string{customFolderKey: folder}
+	}
+	return cm, nil
+}
+
+// loadFile queues the changes of the configmaps of the file since they were loaded before,
+// the file is left as loaded when it cannot be read, e.g. while it is written, the failed
+// changes are retried by the queue
+func (s *directorySource) loadFile(path string) {
+	cms, err := s.readFile(path)
+	if err != nil {
+		klog.Errorf("failed to read dashboard file %v: %v", path, err)
+		return
+	}
+
+	loaded := map[string]*corev1.ConfigMap{}
+	for _, cm := range s.files[path] {
+		loaded[getConfigmapName(cm)] = cm
+	}
+	// the configmaps of the file are found by the dashboards loaded below
+	s.mutex.Lock()
+	if len(cms) == 0 {
+		delete(s.files, path)
+	} else {
+		s.files[path] = cms
+	}
+	s.mutex.Unlock()
+
+	for _, cm := range cms {
+		old := loaded[getConfigmapName(cm)]
+		delete(loaded, getConfigmapName(cm))
+		if reflect.DeepEqual(old, cm) {
+			continue
+		}
+
+		handler := getConfigmapHandler(s.queue.handlers, cm)
+		if old != nil && getConfigmapHandler(s.queue.handlers, old) != handler {
+			s.deleteConfigmap(old)
+			old = nil
+		}
+		if handler == nil {
+			continue
+		}
+		klog.V(2).Infof("queue %v %v of %v", handler.kind, cm.GetName(), path)
+		if old == nil {
+			s.queue.add(nil, cm)
+		} else {
+			s.queue.add(old, cm)
+		}
+	}
+	for _, old := range loaded {
+		s.deleteConfigmap(old)
+	}
+}
+
+// removePath deletes the configmaps loaded from the file, or from the files of the directory
+func (s *directorySource) removePath(path string) {
+	for file, cms := range s.files {
+		if file != path && !strings.HasPrefix(file, path+string(filepath.Separator)) {
+			continue
+		}
+		s.mutex.Lock()
+		delete(s.files, file)
+		s.mutex.Unlock()
+		for _, cm := range cms {
+			s.deleteConfigmap(cm)
+		}
+	}
+}
+
+func (s *directorySource) deleteConfigmap(cm *corev1.ConfigMap) {
+	if getConfigmapHandler(s.queue.handlers, cm) == nil {
+		return
+	}
+	s.queue.addDeleted(cm)
+}
+
+// resync loads all the files of the directory, watching its subdirectories, and deletes
+// the configmaps of the files which are gone
+func (s *directorySource) resync(watcher *fsnotify.Watcher) error {
+	paths := []string{}
+	err := filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if s.isHiddenPath(path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			if watcher != nil {
+				return watcher.Add(path)
+			}
+			return nil
+		}
+		if isManifestFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	found := map[string]bool{}
+	for _, path := range paths {
+		found[path] = true
+		s.loadFile(path)
+	}
+	gone := []string{}
+	for path := range s.files {
+		if !found[path] {
+			gone = append(gone, path)
+		}
+	}
+	sort.Strings(gone)
+	for _, path := range gone {
+		s.removePath(path)
+	}
+	return nil
+}
+
+func (s *directorySource) handleEvent(watcher *fsnotify.Watcher, event fsnotify.Event) {
+	if s.isHiddenPath(event.Name) {
+		// mounted configmaps are updated by swapping the hidden ..data link
+		err := s.resync(watcher)
+		if err != nil {
+			klog.Errorf("failed to load directory %v: %v", s.dir, err)
+		}
+		return
+	}
+
+	if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+		s.removePath(event.Name)
+		return
+	}
+
+	info, err := os.Stat(event.Name)
+	if err != nil {
+		return
+	}
+	if info.IsDir() {
+		// the files of new directories may be created before they are watched
+		err = s.resync(watcher)
+		if err != nil {
+			klog.Errorf("failed to load directory %v: %v", event.Name, err)
+		}
+		return
+	}
+	if isManifestFile(event.Name) {
+		s.loadFile(event.Name)
+	}
+}
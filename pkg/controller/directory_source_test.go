@@ -0,0 +1,150 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// newRecordingSource returns a directory source recording the handled configmaps
+func newRecordingSource(dir string, calls *[]string) *directorySource {
+	handlers := []configmapHandler{
+		{
+			kind:      "dashboard",
+			isDesired: isDesiredDashboardConfigmap,
+			update: func(old, new interface{}) error {
+				cm := new.(*corev1.ConfigMap)
+				*calls = append(*calls, "update "+cm.GetName()+" "+getDashboardCustomFolderTitle(cm))
+				return nil
+			},
+			delete: func(obj interface{}) {
+				*calls = append(*calls, "delete "+obj.(*corev1.ConfigMap).GetName())
+			},
+		},
+	}
+	return newDirectorySource(dir, newConfigmapQueue(handlers))
+}
+
+// processQueue syncs the queued changes of the directory source
+func processQueue(s *directorySource) {
+	for s.queue.queue.Len() > 0 {
+		s.queue.processNextItem()
+	}
+}
+
+func TestDirectorySource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dashboards")
+	if err != nil {
+		t.Fatalf("fail to create directory with %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "Team A"), 0755)
+	os.MkdirAll(filepath.Join(dir, ".hidden"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "overview.json"), []byte(`{"title": "Overview"}`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "Team A", "pods.json"), []byte(`{"title": "Pods"}`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, ".hidden", "skipped.json"), []byte(`{"title": "Skipped"}`), 0644)
+	manifest, _ := ioutil.ReadFile("../../examples/k8s-dashboard.yaml")
+	ioutil.WriteFile(filepath.Join(dir, "Team A", "k8s.yaml"), manifest, 0644)
+
+	calls := []string{}
+	s := newRecordingSource(dir, &calls)
+	defer s.queue.queue.ShutDown()
+	err = s.resync(nil)
+	if err != nil {
+		t.Fatalf("fail to load directory with %v", err)
+	}
+	processQueue(s)
+	expected := []string{
+		"update networking-cluster Team A",
+		"update team-a-pods-json Team A",
+		"update overview-json ",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("the calls %v are not the expected %v", calls, expected)
+	}
+
+	calls = []string{}
+	s.loadFile(filepath.Join(dir, "overview.json"))
+	processQueue(s)
+	if len(calls) != 0 {
+		t.Errorf("the unchanged file should not be loaded again: %v", calls)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "overview.json"), []byte(`{"title": "Changed"`), 0644)
+	s.loadFile(filepath.Join(dir, "overview.json"))
+	processQueue(s)
+	if len(calls) != 0 || len(s.files) != 3 {
+		t.Errorf("the invalid file should be left as loaded: %v", calls)
+	}
+
+	os.RemoveAll(filepath.Join(dir, "Team A"))
+	s.removePath(filepath.Join(dir, "Team A"))
+	processQueue(s)
+	expected = []string{"delete networking-cluster", "delete team-a-pods-json"}
+	sort.Strings(calls)
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("the calls %v are not the expected %v", calls, expected)
+	}
+}
+
+func TestDirectorySourceRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dashboards")
+	if err != nil {
+		t.Fatalf("fail to create directory with %v", err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "overview.json"), []byte(`{"title": "Overview"}`), 0644)
+
+	updated := 0
+	s := newDirectorySource(dir, newConfigmapQueue([]configmapHandler{{
+		kind:      "dashboard",
+		isDesired: isDesiredDashboardConfigmap,
+		update: func(old, new interface{}) error {
+			updated++
+			if updated == 1 {
+				return &retryableError{errors.New("grafana is unavailable")}
+			}
+			return nil
+		},
+		delete: func(obj interface{}) {},
+	}}))
+	defer s.queue.queue.ShutDown()
+
+	// the file is loaded again after the failure, though it did not change
+	s.loadFile(filepath.Join(dir, "overview.json"))
+	s.queue.processNextItem()
+	s.queue.processNextItem()
+	if updated != 2 {
+		t.Errorf("the failed dashboard should be loaded again, updated %v times", updated)
+	}
+}
+
+func TestGetDirectoryFolder(t *testing.T) {
+	s := newDirectorySource("/dashboards", nil)
+	testCaseList := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"top file", "/dashboards/a.json", ""},
+		{"subdirectory file", "/dashboards/Team/a.json", "Team"},
+		{"nested file", "/dashboards/Team/sub/a.json", "Team"},
+	}
+
+	for _, c := range testCaseList {
+		output := s.getFolder(c.path)
+		if output != c.expected {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+const eventComponent = "grafana-dashboard-loader"
+
+// eventRecorder records events on the configmaps, nil without kubernetes
+var eventRecorder record.EventRecorder
+
+func startEventRecorder(coreClient corev1client.CoreV1Interface) {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&corev1client.EventSinkImpl{Interface: coreClient.Events("")})
+	eventRecorder = broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: eventComponent})
+}
+
+// recordWarning logs the problem of the configmap and records it as a warning event
+func recordWarning(cm *corev1.ConfigMap, reason, message string) {
+	klog.Errorf("%v %v: %v", reason, getConfigmapName(cm), message)
+	// configmaps of manifest files or directories have no uid to refer to
+	if eventRecorder == nil || cm.GetUID() == "" {
+		return
+	}
+	eventRecorder.Event(cm, corev1.EventTypeWarning, reason, message)
+}
+
+// recordEvent logs what the loader did to resolve a problem of the configmap and records it
+// as a normal event
+func recordEvent(cm *corev1.ConfigMap, reason, message string) {
+	klog.Infof("%v %v: %v", reason, getConfigmapName(cm), message)
+	if eventRecorder == nil || cm.GetUID() == "" {
+		return
+	}
+	eventRecorder.Event(cm, corev1.EventTypeNormal, reason, message)
+}
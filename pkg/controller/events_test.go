@@ -0,0 +1,39 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecordWarning(t *testing.T) {
+	recorder := record.NewFakeRecorder(2)
+	eventRecorder = recorder
+	defer func() { eventRecorder = nil }()
+
+	recordWarning(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "file"}}, "InvalidDashboard", "no uid")
+	recordWarning(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cluster", UID: "1"}}, "InvalidDashboard", "invalid")
+
+	if len(recorder.Events) != 1 {
+		t.Fatalf("the events %v are not the expected one", len(recorder.Events))
+	}
+	if event := <-recorder.Events; event != "Warning InvalidDashboard invalid" {
+		t.Errorf("the event %v is not the expected", event)
+	}
+}
+
+func TestRecordEvent(t *testing.T) {
+	recorder := record.NewFakeRecorder(1)
+	eventRecorder = recorder
+	defer func() { eventRecorder = nil }()
+
+	recordEvent(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cluster", UID: "1"}}, "DashboardNameConflictResolved", "adopted")
+	if event := <-recorder.Events; event != "Normal DashboardNameConflictResolved adopted" {
+		t.Errorf("the event %v is not the expected", event)
+	}
+}
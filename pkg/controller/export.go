@@ -0,0 +1,155 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+const (
+	generalFolderTitle = "General"
+	// maxConfigmapNameLength keeps the exported names usable as label values
+	maxConfigmapNameLength = 63
+)
+
+var invalidNameChars = regexp.MustCompile("[^a-z0-9]+")
+
+// ExportOptions selects the grafana dashboards to export
+type ExportOptions struct {
+	GrafanaURL string
+	// Folders are folder titles, General for the general folder
+	Folders   []string
+	Tags      []string
+	Namespace string
+}
+
+// ExportDashboards downloads the matching grafana dashboards as dashboard configmaps
+// ready to be loaded into the same folders
+func ExportDashboards(opts ExportOptions) ([]*corev1.ConfigMap, error) {
+	if opts.GrafanaURL != "" {
+		grafanaURI = opts.GrafanaURL
+	}
+
+	query := url.Values{}
+	query.Set("type", "dash-db")
+	for _, folder := range opts.Folders {
+		folderID := 0.0
+		if folder != generalFolderTitle {
+			folderID = hasCustomFolder(folder)
+			if folderID == 0 {
+				return nil, fmt.Errorf("folder %v not found", folder)
+			}
+		}
+		query.Add("folderIds", fmt.Sprint(folderID))
+	}
+	for _, tag := range opts.Tags {
+		query.Add("tag", tag)
+	}
+
+	grafanaURL := grafanaURI + "/api/search?" + query.Encode()
+	body, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to search dashboards with %v", respStatusCode)
+	}
+	results := []map[string]interface{}{}
+	err := json.Unmarshal(body, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	cms := []*corev1.ConfigMap{}
+	names := map[string]bool{}
+	for _, result := range results {
+		uid, _ := result["uid"].(string)
+		dashboard, folder, found := getLiveDashboard(uid)
+		if !found {
+			return nil, fmt.Errorf("failed to get dashboard %v", uid)
+		}
+		cm, err := newDashboardConfigmap(dashboard, folder, opts.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		// dashboards with the same title in different folders get distinct configmaps
+		cm.Name = getUniqueConfigmapName(cm.Name, uid, names)
+		cms = append(cms, cm)
+	}
+	return cms, nil
+}
+
+// newDashboardConfigmap creates the configmap loading the dashboard into the folder
+func newDashboardConfigmap(dashboard map[string]interface{}, folder, namespace string) (*corev1.ConfigMap, error) {
+	dashboard = normalizeDashboard(dashboard)
+	uid, _ := dashboard["uid"].(string)
+	title, _ := dashboard["title"].(string)
+	b, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getExportedConfigmapName(title, uid),
+			Namespace: namespace,
+			Labels:    map[string]string{"grafana-custom-dashboard": "true"},
+		},
+		Data: map[string]string{
+			getExportedConfigmapName(title, uid) + ".json": string(b),
+		},
+	}
+	if folder == "" {
+		cm.ObjectMeta.Labels[generalFolderKey] = "true"
+	} else {
+		cm.ObjectMeta.Annotations = map[string]string{customFolderKey: folder}
+	}
+	return cm, nil
+}
+
+// getExportedConfigmapName returns a valid configmap name derived from the dashboard title
+func getExportedConfigmapName(title, uid string) string {
+	name := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if name == "" {
+		name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(uid), "-"), "-")
+	}
+	if len(name) > maxConfigmapNameLength {
+		name = strings.TrimRight(name[:maxConfigmapNameLength], "-")
+	}
+	return name
+}
+
+// getUniqueConfigmapName returns the name, or the name with the dashboard uid as suffix when
+// it is taken already, and records it as taken
+func getUniqueConfigmapName(name, uid string, names map[string]bool) string {
+	unique := name
+	for i := 1; names[unique]; i++ {
+		parts := []string{}
+		if suffix := getExportedConfigmapName("", uid); suffix != "" {
+			parts = append(parts, suffix)
+		}
+		if i > 1 || len(parts) == 0 {
+			parts = append(parts, fmt.Sprint(i))
+		}
+		suffix := "-" + strings.Join(parts, "-")
+		prefix := name
+		if len(prefix)+len(suffix) > maxConfigmapNameLength {
+			prefix = strings.TrimRight(prefix[:maxConfigmapNameLength-len(suffix)], "-")
+		}
+		unique = prefix + suffix
+	}
+	names[unique] = true
+	return unique
+}
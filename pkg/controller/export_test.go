@@ -0,0 +1,93 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"testing"
+)
+
+func TestGetExportedConfigmapName(t *testing.T) {
+	testCaseList := []struct {
+		name     string
+		title    string
+		uid      string
+		expected string
+	}{
+		{"title", "ACM - Clusters Overview", "abc", "acm-clusters-overview"},
+		{"no title", "", "2b679d600f3b9e7676a7c5ac3643d448", "2b679d600f3b9e7676a7c5ac3643d448"},
+		{"long title", "Kubernetes / Compute Resources / Namespace (Pods) of the managed clusters in the fleet", "x",
+			"kubernetes-compute-resources-namespace-pods-of-the-managed-clus"},
+	}
+
+	for _, c := range testCaseList {
+		output := getExportedConfigmapName(c.title, c.uid)
+		if output != c.expected {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
+
+func TestGetUniqueConfigmapName(t *testing.T) {
+	names := map[string]bool{}
+	testCaseList := []struct {
+		name     string
+		cmName   string
+		uid      string
+		expected string
+	}{
+		{"first", "overview", "a1", "overview"},
+		{"same title in another folder", "overview", "B2", "overview-b2"},
+		{"same title and uid", "overview", "b2", "overview-b2-2"},
+		{"long title", "kubernetes-compute-resources-namespace-pods-of-the-managed-clus", "x", "kubernetes-compute-resources-namespace-pods-of-the-managed-clus"},
+		{"same long title", "kubernetes-compute-resources-namespace-pods-of-the-managed-clus", "y", "kubernetes-compute-resources-namespace-pods-of-the-managed-cl-y"},
+	}
+
+	for _, c := range testCaseList {
+		output := getUniqueConfigmapName(c.cmName, c.uid, names)
+		if output != c.expected {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
+
+func TestNewDashboardConfigmap(t *testing.T) {
+	dashboard := map[string]interface{}{"id": 3, "version": 5, "uid": "abc", "title": "Test"}
+
+	cm, err := newDashboardConfigmap(dashboard, "Team", "ns")
+	if err != nil {
+		t.Fatalf("fail to create configmap with %v", err)
+	}
+	if !isDesiredDashboardConfigmap(cm) || getDashboardCustomFolderTitle(cm) != "Team" {
+		t.Errorf("the configmap %v should load the dashboard into the Team folder", cm)
+	}
+	exported, err := parseDashboard(cm, "test.json")
+	if err != nil {
+		t.Fatalf("fail to parse the exported dashboard with %v", err)
+	}
+	if exported["uid"] != "abc" || exported["id"] != nil || exported["version"] != nil {
+		t.Errorf("the exported dashboard %v is not the expected", exported)
+	}
+
+	cm, _ = newDashboardConfigmap(dashboard, "", "ns")
+	if getDashboardCustomFolderTitle(cm) != "" {
+		t.Errorf("the configmap %v should load the dashboard into the general folder", cm)
+	}
+}
+
+func TestExportDashboards(t *testing.T) {
+	startFakeServer(t)
+
+	_, err := ExportDashboards(ExportOptions{Folders: []string{"Missing"}})
+	if err == nil {
+		t.Errorf("exporting a missing folder should fail")
+	}
+
+	cms, err := ExportDashboards(ExportOptions{Folders: []string{"Custom"}})
+	if err != nil {
+		t.Fatalf("fail to export dashboards with %v", err)
+	}
+	if len(cms) != 0 {
+		t.Errorf("the configmaps %v are not the expected none", cms)
+	}
+}
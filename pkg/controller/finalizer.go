@@ -0,0 +1,132 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+const (
+	// dashboardFinalizer blocks the deletion of dashboard configmaps until their dashboards
+	// are deleted from grafana
+	dashboardFinalizer = "observability.open-cluster-management.io/grafana-dashboard-loader"
+	// skipCleanupKey is the annotation removing the finalizer without deleting the dashboards,
+	// e.g. when grafana is gone for good
+	skipCleanupKey = "observability.open-cluster-management.io/skip-dashboard-cleanup"
+)
+
+var (
+	// UseFinalizers adds the finalizer to the dashboard configmaps
+	UseFinalizers = false
+	// FinalizerTimeout is the time after which the finalizer is removed even if the dashboards
+	// cannot be deleted
+	FinalizerTimeout = time.Hour
+)
+
+func hasDashboardFinalizer(cm *corev1.ConfigMap) bool {
+	for _, finalizer := range cm.GetFinalizers() {
+		if finalizer == dashboardFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// isDeleting returns whether the configmap is waiting for finalizers to be deleted
+func isDeleting(obj interface{}) bool {
+	cm, ok := obj.(*corev1.ConfigMap)
+	return ok && cm != nil && cm.GetDeletionTimestamp() != nil
+}
+
+// addDashboardFinalizer adds the finalizer to the dashboard configmap when finalizers are used,
+// the configmaps are not changed in dry run mode
+func addDashboardFinalizer(coreClient corev1client.CoreV1Interface, obj interface{}) {
+	cm := obj.(*corev1.ConfigMap)
+	if !UseFinalizers || util.DryRun || coreClient == nil || hasDashboardFinalizer(cm) || isDeleting(cm) {
+		return
+	}
+
+	latest, err := coreClient.ConfigMaps(cm.Namespace).Get(context.TODO(), cm.Name, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("failed to get configmap %v to add the finalizer: %v", getConfigmapName(cm), err)
+		return
+	}
+	if hasDashboardFinalizer(latest) || isDeleting(latest) {
+		return
+	}
+	latest.Finalizers = append(latest.Finalizers, dashboardFinalizer)
+	_, err = coreClient.ConfigMaps(cm.Namespace).Update(context.TODO(), latest, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Errorf("failed to add the finalizer to configmap %v: %v", getConfigmapName(cm), err)
+	}
+}
+
+// removeDashboardFinalizer removes the finalizer from the latest version of the configmap
+func removeDashboardFinalizer(coreClient corev1client.CoreV1Interface, cm *corev1.ConfigMap) error {
+	if util.DryRun {
+		klog.Infof("dry run: skip removing the finalizer from configmap %v", getConfigmapName(cm))
+		return nil
+	}
+	latest, err := coreClient.ConfigMaps(cm.Namespace).Get(context.TODO(), cm.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get configmap %v to remove the finalizer: %v", getConfigmapName(cm), err)
+	}
+
+	finalizers := []string{}
+	for _, finalizer := range latest.Finalizers {
+		if finalizer != dashboardFinalizer {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	if len(finalizers) == len(latest.Finalizers) {
+		return nil
+	}
+	latest.Finalizers = finalizers
+	_, err = coreClient.ConfigMaps(cm.Namespace).Update(context.TODO(), latest, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to remove the finalizer from configmap %v: %v", getConfigmapName(cm), err)
+	}
+	return nil
+}
+
+// finalizeDashboard deletes the dashboards of the configmap being deleted and removes its
+// finalizer, the finalizer is kept when the dashboards cannot be deleted until the timeout
+func finalizeDashboard(coreClient corev1client.CoreV1Interface, obj interface{}) error {
+	cm := obj.(*corev1.ConfigMap)
+	if strings.ToLower(cm.Annotations[skipCleanupKey]) == "true" {
+		recordEvent(cm, "DashboardCleanupSkipped", "the dashboards are left in grafana as "+skipCleanupKey+" is set")
+	} else {
+		err := deleteDashboards(cm)
+		if err != nil {
+			deleting := time.Since(cm.GetDeletionTimestamp().Time)
+			if deleting < FinalizerTimeout {
+				recordWarning(cm, "DashboardCleanupFailed", err.Error())
+				return err
+			}
+			recordWarning(cm, "DashboardCleanupTimeout", fmt.Sprintf("%v, remove the finalizer after %v", err, deleting.Round(time.Second)))
+		}
+	}
+
+	if coreClient == nil || !hasDashboardFinalizer(cm) {
+		return nil
+	}
+	return removeDashboardFinalizer(coreClient, cm)
+}
+
+// isFinalizedDashboardConfigmap returns whether the configmap has the finalizer, it is finalized
+// even if it is not labeled as dashboard configmap anymore
+func isFinalizedDashboardConfigmap(obj interface{}) bool {
+	cm, ok := obj.(*corev1.ConfigMap)
+	return ok && cm != nil && hasDashboardFinalizer(cm)
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+func TestAddDashboardFinalizer(t *testing.T) {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"}}
+	coreClient := fake.NewSimpleClientset(cm).CoreV1()
+
+	addDashboardFinalizer(coreClient, cm)
+	latest, _ := coreClient.ConfigMaps("ns").Get(context.TODO(), "test", metav1.GetOptions{})
+	if hasDashboardFinalizer(latest) {
+		t.Errorf("the finalizer should not be added unless enabled")
+	}
+
+	UseFinalizers = true
+	defer func() { UseFinalizers = false }()
+	util.DryRun = true
+	addDashboardFinalizer(coreClient, cm)
+	util.DryRun = false
+	latest, _ = coreClient.ConfigMaps("ns").Get(context.TODO(), "test", metav1.GetOptions{})
+	if hasDashboardFinalizer(latest) {
+		t.Errorf("the finalizer should not be added in dry run mode")
+	}
+
+	addDashboardFinalizer(coreClient, cm)
+	addDashboardFinalizer(coreClient, cm)
+	latest, _ = coreClient.ConfigMaps("ns").Get(context.TODO(), "test", metav1.GetOptions{})
+	if len(latest.Finalizers) != 1 || !hasDashboardFinalizer(latest) {
+		t.Errorf("the finalizers %v are not the expected", latest.Finalizers)
+	}
+}
+
+func TestFinalizeDashboard(t *testing.T) {
+	deleteStatus := http.StatusOK
+	deleted := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/folders", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("[]"))
+	})
+	mux.HandleFunc("/api/dashboards/uid/", func(w http.ResponseWriter, req *http.Request) {
+		deleted++
+		w.WriteHeader(deleteStatus)
+		w.Write([]byte("{}"))
+	})
+	startGrafanaServer(t, mux)
+
+	testCaseList := []struct {
+		name         string
+		deleteStatus int
+		deletedSince time.Duration
+		annotations  map[string]string
+		data         string
+		deleted      int
+		finalized    bool
+	}{
+		{"deleted", http.StatusOK, 0, nil, `{"title": "A", "uid": "a"}`, 1, true},
+		{"deleted already", http.StatusNotFound, 0, nil, `{"title": "A", "uid": "a"}`, 1, true},
+		{"failed", http.StatusForbidden, 0, nil, `{"title": "A", "uid": "a"}`, 1, false},
+		{"invalid dashboard", http.StatusNotFound, 0, nil, `{"title": "A"`, 1, true},
+		{"invalid adopted dashboard", http.StatusOK, 0, map[string]string{adoptedDashboardsKey: `{"a.json": "manual"}`}, `{"title": "A"`, 1, true},
+		{"invalid adopted dashboard failed", http.StatusForbidden, 0, map[string]string{adoptedDashboardsKey: `{"a.json": "manual"}`}, `{"title": "A"`, 1, false},
+		{"timed out", http.StatusForbidden, 2 * FinalizerTimeout, nil, `{"title": "A", "uid": "a"}`, 1, true},
+		{"skipped", http.StatusForbidden, 0, map[string]string{skipCleanupKey: "true"}, `{"title": "A", "uid": "a"}`, 0, true},
+	}
+
+	for _, c := range testCaseList {
+		deleteStatus = c.deleteStatus
+		deleted = 0
+		deletionTimestamp := metav1.NewTime(time.Now().Add(-c.deletedSince))
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "test",
+				Namespace:         "ns",
+				Annotations:       c.annotations,
+				Finalizers:        []string{"other", dashboardFinalizer},
+				DeletionTimestamp: &deletionTimestamp,
+			},
+			Data: map[string]string{"a.json": c.data},
+		}
+		coreClient := fake.NewSimpleClientset(cm).CoreV1()
+
+		err := finalizeDashboard(coreClient, cm)
+		latest, _ := coreClient.ConfigMaps("ns").Get(context.TODO(), "test", metav1.GetOptions{})
+		if (err == nil) != c.finalized || hasDashboardFinalizer(latest) == c.finalized {
+			t.Errorf("case (%v) output: (%v) with finalizers %v is not the expected finalized: (%v)", c.name, err, latest.Finalizers, c.finalized)
+		}
+		if c.finalized && len(latest.Finalizers) != 1 {
+			t.Errorf("case (%v) the other finalizers %v should be kept", c.name, latest.Finalizers)
+		}
+		if deleted != c.deleted {
+			t.Errorf("case (%v) deleted %v dashboards instead of %v", c.name, deleted, c.deleted)
+		}
+	}
+}
+
+func TestUnlabeledDashboardFinalizer(t *testing.T) {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns", Finalizers: []string{dashboardFinalizer}}}
+	handler := getConfigmapHandler(getConfigmapHandlers(nil), cm)
+	if handler == nil || handler.finalize == nil {
+		t.Errorf("the configmap with the finalizer should be finalized without the dashboard label")
+	}
+
+	cm.Finalizers = nil
+	if getConfigmapHandler(getConfigmapHandlers(nil), cm) != nil {
+		t.Errorf("the configmap without label and finalizer should not be handled")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"sync"
+
+	"k8s.io/klog"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+// loaderFolderUIDPrefix prefixes the uids of the folders created by the loader, only these
+// folders are deleted by the loader
+const loaderFolderUIDPrefix = "loader-"
+
+// grafanaFolder is a folder in grafana
+type grafanaFolder struct {
+	id    float64
+	uid   string
+	title string
+}
+
+// isLoaderFolder returns whether the folder was created by the loader
+func (f grafanaFolder) isLoaderFolder() bool {
+	return strings.HasPrefix(f.uid, loaderFolderUIDPrefix)
+}
+
+// getLoaderFolderUID returns the uid of the folder with the title created by the loader
+func getLoaderFolderUID(title string) string {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(title))
+	return loaderFolderUIDPrefix + hex.EncodeToString(hasher.Sum(nil))
+}
+
+// folderCache caches the grafana folders by title and id, it is refreshed on misses
+type folderCache struct {
+	byTitle map[string]grafanaFolder
+	byID    map[float64]grafanaFolder
+	mutex   sync.Mutex
+}
+
+var folders = newFolderCache()
+
+func newFolderCache() *folderCache {
+	return &folderCache{
+		byTitle: map[string]grafanaFolder{},
+		byID:    map[float64]grafanaFolder{},
+	}
+}
+
+// refresh replaces the cached folders with the folders in grafana
+func (c *folderCache) refresh() bool {
+	grafanaURL := grafanaURI + "/api/folders"
+	body, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK {
+		klog.Errorf("failed to get folders with %v", respStatusCode)
+		return false
+	}
+
+	list := []map[string]interface{}{}
+	err := json.Unmarshal(body, &list)
+	if err != nil {
+		klog.Error(unmarshallErrMsg, "error", err)
+		return false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.byTitle = map[string]grafanaFolder{}
+	c.byID = map[float64]grafanaFolder{}
+	for _, item := range list {
+		folder := grafanaFolder{}
+		folder.id, _ = item["id"].(float64)
+		folder.uid, _ = item["uid"].(string)
+		folder.title, _ = item["title"].(string)
+		c.byTitle[folder.title] = folder
+		c.byID[folder.id] = folder
+	}
+	return true
+}
+
+func (c *folderCache) cachedByTitle(title string) (grafanaFolder, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	folder, ok := c.byTitle[title]
+	return folder, ok
+}
+
+func (c *folderCache) cachedByID(id float64) (grafanaFolder, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	folder, ok := c.byID[id]
+	return folder, ok
+}
+
+// getByTitle returns the folder with the title, refreshing the cache on a miss
+func (c *folderCache) getByTitle(title string) (grafanaFolder, bool) {
+	if folder, ok := c.cachedByTitle(title); ok {
+		return folder, true
+	}
+	if !c.refresh() {
+		return grafanaFolder{}, false
+	}
+	return c.cachedByTitle(title)
+}
+
+// getByID returns the folder with the id, refreshing the cache on a miss
+func (c *folderCache) getByID(id float64) (grafanaFolder, bool) {
+	if folder, ok := c.cachedByID(id); ok {
+		return folder, true
+	}
+	if !c.refresh() {
+		return grafanaFolder{}, false
+	}
+	return c.cachedByID(id)
+}
+
+func (c *folderCache) add(folder grafanaFolder) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.byTitle[folder.title] = folder
+	c.byID[folder.id] = folder
+}
+
+// remove invalidates the folder with the id, e.g. once deleted
+func (c *folderCache) remove(id float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if folder, ok := c.byID[id]; ok {
+		delete(c.byTitle, folder.title)
+		delete(c.byID, id)
+	}
+}
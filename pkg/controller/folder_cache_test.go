@@ -0,0 +1,71 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestFolderCache(t *testing.T) {
+	listed := 0
+	created := map[string]string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/folders", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			b, _ := ioutil.ReadAll(req.Body)
+			json.Unmarshal(b, &created)
+			w.Write([]byte(`{"id": 5, "uid": "new", "title": "New"}`))
+			return
+		}
+		listed++
+		w.Write([]byte(`[{"id": 4, "uid": "team", "title": "Team"}]`))
+	})
+	mux.HandleFunc("/api/folders/team", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/search", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	startGrafanaServer(t, mux)
+
+	for i := 0; i < 3; i++ {
+		if hasCustomFolder("Team") != 4 || getCustomFolderUID(4) != "team" {
+			t.Fatalf("the Team folder is not the expected")
+		}
+	}
+	if listed != 1 {
+		t.Errorf("the folders are listed %v times instead of once", listed)
+	}
+
+	if createCustomFolder("New") != 5 || getCustomFolderUID(5) != "new" || listed != 2 {
+		t.Errorf("the created folder should be cached after one listing, listed %v times", listed)
+	}
+	if created["title"] != "New" || created["uid"] != getLoaderFolderUID("New") {
+		t.Errorf("the folder %v is not created with the uid of the loader", created)
+	}
+
+	if hasCustomFolder("") != 0 || listed != 2 {
+		t.Errorf("the general folder should not be listed")
+	}
+
+	if !isEmptyFolder(4) || deleteCustomFolder(4) {
+		t.Errorf("the missing Team folder should fail to be deleted")
+	}
+	if _, ok := folders.cachedByID(4); ok {
+		t.Errorf("the missing Team folder should be removed from the cache")
+	}
+}
+
+func TestGetLoaderFolderUID(t *testing.T) {
+	uid := getLoaderFolderUID("Team \"A\"")
+	if uid != getLoaderFolderUID("Team \"A\"") || uid == getLoaderFolderUID("Team B") {
+		t.Errorf("the uid %v should only depend on the title", uid)
+	}
+	if len(uid) > maxUIDLength || !(grafanaFolder{uid: uid}).isLoaderFolder() || (grafanaFolder{uid: "team"}).isLoaderFolder() {
+		t.Errorf("the uid %v is not a valid uid of the loader", uid)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const gnetLatestRevision = "latest"
+
+var (
+	// GnetMirror is the directory or http url of the mirror of grafana.com dashboards, laid
+	// out as <gnetId>/<revision>.json
+	GnetMirror = ""
+	// gnetClient fetches dashboards from http mirrors
+	gnetClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+// isGnetReference returns whether the dashboard only refers to a grafana.com dashboard
+func isGnetReference(dashboard map[string]interface{}) bool {
+	if _, ok := dashboard["gnetId"]; !ok {
+		return false
+	}
+	_, hasPanels := dashboard["panels"]
+	_, hasRows := dashboard["rows"]
+	return !hasPanels && !hasRows
+}
+
+// getGnetMirrorPath returns the path of the revision of the dashboard in the mirror
+func getGnetMirrorPath(dashboard map[string]interface{}) (string, error) {
+	id := fmt.Sprint(dashboard["gnetId"])
+	if f, ok := dashboard["gnetId"].(float64); ok {
+		id = fmt.Sprintf("%.0f", f)
+	}
+	revision := gnetLatestRevision
+	switch r := dashboard["revision"].(type) {
+	case float64:
+		revision = fmt.Sprintf("%.0f", r)
+	case string:
+		if r != "" {
+			revision = r
+		}
+	}
+	if strings.ContainsAny(id+revision, "/\\.") {
+		return "", fmt.Errorf("invalid gnetId %v revision %v", id, revision)
+	}
+	return id + "/" + revision + ".json", nil
+}
+
+func readGnetMirror(path string) ([]byte, error) {
+	if !strings.HasPrefix(GnetMirror, "http://") && !strings.HasPrefix(GnetMirror, "https://") {
+		return ioutil.ReadFile(filepath.Join(GnetMirror, filepath.FromSlash(path)))
+	}
+
+	resp, err := gnetClient.Get(strings.TrimRight(GnetMirror, "/") + "/" + path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %v from the mirror with %v", path, resp.StatusCode)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// resolveGnetDashboard returns the grafana.com dashboard the dashboard refers to from the
+// mirror, with the other fields of the dashboard, e.g. uid or title, overriding its fields
+func resolveGnetDashboard(dashboard map[string]interface{}) (map[string]interface{}, error) {
+	if !isGnetReference(dashboard) {
+		return dashboard, nil
+	}
+	if GnetMirror == "" {
+		return nil, fmt.Errorf("gnetId %v cannot be resolved without a mirror", dashboard["gnetId"])
+	}
+
+	path, err := getGnetMirrorPath(dashboard)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readGnetMirror(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gnetId %v: %v", dashboard["gnetId"], err)
+	}
+	resolved := map[string]interface{}{}
+	err = json.Unmarshal(data, &resolved)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dashboard %v in the mirror: %v", path, err)
+	}
+
+	for key, value := range dashboard {
+		if key != "revision" {
+			resolved[key] = value
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const gnetDashboard = `{
+  "__inputs": [{"name": "DS_PROMETHEUS", "type": "datasource", "pluginId": "prometheus"}],
+  "uid": "gnet-uid",
+  "title": "Networking",
+  "panels": [{"id": 1, "datasource": "${DS_PROMETHEUS}"}]
+}`
+
+func TestGetGnetMirrorPath(t *testing.T) {
+	testCaseList := []struct {
+		name      string
+		dashboard map[string]interface{}
+		expected  string
+	}{
+		{"revision", map[string]interface{}{"gnetId": float64(12124), "revision": float64(2)}, "12124/2.json"},
+		{"latest", map[string]interface{}{"gnetId": float64(12124)}, "12124/latest.json"},
+		{"invalid", map[string]interface{}{"gnetId": "../12124"}, ""},
+	}
+
+	for _, c := range testCaseList {
+		output, _ := getGnetMirrorPath(c.dashboard)
+		if output != c.expected {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
+
+func TestResolveGnetDashboard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gnet")
+	if err != nil {
+		t.Fatalf("fail to create directory with %v", err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "12124"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "12124", "2.json"), []byte(gnetDashboard), 0644)
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer server.Close()
+	defer func() {
+		GnetMirror = ""
+		DefaultDatasource = ""
+	}()
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "gnet", Namespace: "ns"},
+		Data: map[string]string{
+			"networking.json": `{"gnetId": 12124, "revision": 2, "title": "Hub networking"}`,
+			"missing.json":    `{"gnetId": 1}`,
+			"inline.json":     `{"gnetId": 12124, "panels": []}`,
+		},
+	}
+
+	if _, err := parseDashboard(cm, "networking.json"); err == nil {
+		t.Errorf("resolving without a mirror should fail")
+	}
+	if _, err := parseDashboard(cm, "inline.json"); err != nil {
+		t.Errorf("dashboards with panels should not be resolved: %v", err)
+	}
+
+	for _, mirror := range []string{dir, server.URL} {
+		GnetMirror = mirror
+		DefaultDatasource = ""
+		if _, err := parseDashboard(cm, "networking.json"); err == nil {
+			t.Errorf("resolving from %v without a default datasource should fail", mirror)
+		}
+
+		DefaultDatasource = "Observatorium"
+		dashboard, err := parseDashboard(cm, "networking.json")
+		if err != nil {
+			t.Fatalf("fail to resolve from %v with %v", mirror, err)
+		}
+		panel := dashboard["panels"].([]interface{})[0].(map[string]interface{})
+		if dashboard["title"] != "Hub networking" || dashboard["uid"] != "gnet-uid" ||
+			dashboard["__inputs"] != nil || panel["datasource"] != "Observatorium" {
+			t.Errorf("the dashboard %v resolved from %v is not the expected", dashboard, mirror)
+		}
+
+		if _, err := parseDashboard(cm, "missing.json"); err == nil {
+			t.Errorf("resolving a dashboard missing in %v should fail", mirror)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+const (
+	// homeDashboardKey marks the dashboard of a configmap as the home dashboard.
+	// The value is a comma separated list of scopes, e.g. "org,team:sre,user:alice"
+	homeDashboardKey = "observability.open-cluster-management.io/home-dashboard"
+	// homeDashboardDataKey selects the data key of the home dashboard when the
+	// configmap contains more than one dashboard
+	homeDashboardDataKey = "observability.open-cluster-management.io/home-dashboard-key"
+	// previousHomeDashboardsKey records the home dashboards which were in place before the
+	// configmap's dashboard was set, by scope as a json object, so that they are restored
+	// after restarts of the loader
+	previousHomeDashboardsKey = "observability.open-cluster-management.io/previous-home-dashboards"
+	orgScope                  = "org"
+	teamScopePrefix           = "team:"
+	userScopePrefix           = "user:"
+)
+
+// homeStack records the home dashboards set by the loader for one scope, the
+// latest one last, and the home dashboard which was in place before the loader
+// changed it
+type homeStack struct {
+	base string
+	uids []string
+}
+
+var (
+	homeStacks = map[string]*homeStack{}
+	// homeScopeLocks serialize the changes of the home dashboard of each scope in grafana
+	homeScopeLocks = map[string]*sync.Mutex{}
+	// homeMutex guards the home stacks and scope locks, it is not held while calling grafana
+	homeMutex sync.Mutex
+)
+
+// getHomeDashboardScopes returns the scopes the configmap's home dashboard is set for
+func getHomeDashboardScopes(obj interface{}) []string {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return nil
+	}
+
+	scopes := []string{}
+	for _, scope := range strings.Split(cm.ObjectMeta.Annotations[homeDashboardKey], ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		if strings.ToLower(scope) == "true" {
+			scope = orgScope
+		}
+		if scope != orgScope && !strings.HasPrefix(scope, teamScopePrefix) && !strings.HasPrefix(scope, userScopePrefix) {
+			klog.Errorf("invalid home dashboard scope %v in configmap %v", scope, cm.Name)
+			continue
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// getHomeDashboardDataKey returns the data key of the configmap's home dashboard
+func getHomeDashboardDataKey(obj interface{}) string {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return ""
+	}
+
+	if key := cm.ObjectMeta.Annotations[homeDashboardDataKey]; key != "" {
+		return key
+	}
+	keys := getDashboardKeys(cm)
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[0]
+}
+
+func getTeamID(teamName string) float64 {
+	grafanaURL := grafanaURI + "/api/teams/search?name=" + url.QueryEscape(teamName)
+	body, _ := util.SetRequest("GET", grafanaURL, nil, retry)
+
+	result := struct {
+		Teams []map[string]interface{} `json:"teams"`
+	}{}
+	err := json.Unmarshal(body, &result)
+	if err != nil {
+		klog.Error(unmarshallErrMsg, "error", err)
+		return 0
+	}
+
+	for _, team := range result.Teams {
+		if team["name"] == teamName {
+			return team["id"].(float64)
+		}
+	}
+	return 0
+}
+
+func getPreferencesURL(scope string) string {
+	if scope == orgScope {
+		return grafanaURI + "/api/org/preferences"
+	}
+	if strings.HasPrefix(scope, userScopePrefix) {
+		return grafanaURI + "/api/user/preferences"
+	}
+
+	teamName := strings.TrimPrefix(scope, teamScopePrefix)
+	teamID := getTeamID(teamName)
+	if teamID == 0 {
+		klog.Errorf("failed to find team %v", teamName)
+		return ""
+	}
+	return grafanaURI + "/api/teams/" + fmt.Sprint(teamID) + "/preferences"
+}
+
+// getPreferencesUser returns the grafana user the preferences of the scope are changed as, the
+// preferences of a user scope are the preferences of the user, which must exist in grafana as
+// impersonating an unknown login would sign it up
+func getPreferencesUser(scope string) (string, bool) {
+	if !strings.HasPrefix(scope, userScopePrefix) {
+		return util.GetGrafanaUser(), true
+	}
+
+	login := strings.TrimPrefix(scope, userScopePrefix)
+	grafanaURL := grafanaURI + "/api/users/lookup?loginOrEmail=" + url.QueryEscape(login)
+	body, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK {
+		klog.Errorf("failed to find user %v with %v", login, respStatusCode)
+		return "", false
+	}
+
+	user := map[string]interface{}{}
+	err := json.Unmarshal(body, &user)
+	if err != nil {
+		klog.Error(unmarshallErrMsg, "error", err)
+		return "", false
+	}
+	if user["login"] != login {
+		klog.Errorf("failed to find user %v, found %v", login, user["login"])
+		return "", false
+	}
+	return login, true
+}
+
+func getPreferences(grafanaURL, user string) map[string]interface{} {
+	body, respStatusCode := util.SetRequestAsUser(user, "GET", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK {
+		klog.Errorf("failed to get preferences with %v", respStatusCode)
+		return nil
+	}
+
+	preferences := map[string]interface{}{}
+	err := json.Unmarshal(body, &preferences)
+	if err != nil {
+		klog.Error(unmarshallErrMsg, "error", err)
+		return nil
+	}
+	return preferences
+}
+
+// putHomeDashboard sets the home dashboard of the scope and returns the previous one
+func putHomeDashboard(scope, uid string) (string, bool) {
+	grafanaURL := getPreferencesURL(scope)
+	if grafanaURL == "" {
+		return "", false
+	}
+	user, ok := getPreferencesUser(scope)
+	if !ok {
+		return "", false
+	}
+	preferences := getPreferences(grafanaURL, user)
+	if preferences == nil {
+		return "", false
+	}
+
+	previous, _ := preferences["homeDashboardUID"].(string)
+	// homeDashboardId is deprecated and would take precedence over the UID
+	delete(preferences, "homeDashboardId")
+	preferences["homeDashboardUID"] = uid
+
+	b, err := json.Marshal(preferences)
+	if err != nil {
+		klog.Error("failed to marshal body", "error", err)
+		return "", false
+	}
+	_, respStatusCode := util.SetRequestAsUser(user, "PUT", grafanaURL, bytes.NewBuffer(b), retry)
+	if respStatusCode != http.StatusOK {
+		klog.Infof("failed to set home dashboard for %v: %v", scope, respStatusCode)
+		return "", false
+	}
+
+	klog.Infof("Home dashboard is set to %v for %v", uid, scope)
+	return previous, true
+}
+
+// getPreviousHomeDashboards returns the home dashboards recorded on the configmap by scope
+func getPreviousHomeDashboards(cm *corev1.ConfigMap) map[string]string {
+	previous := map[string]string{}
+	value, ok := cm.Annotations[previousHomeDashboardsKey]
+	if !ok {
+		return previous
+	}
+	err := json.Unmarshal([]byte(value), &previous)
+	if err != nil {
+		klog.Warningf("invalid %v annotation of %v: %v", previousHomeDashboardsKey, getConfigmapName(cm), err)
+		return map[string]string{}
+	}
+	return previous
+}
+
+// recordPreviousHomeDashboards records the home dashboards by scope on the configmap
+func recordPreviousHomeDashboards(cm *corev1.ConfigMap, previous map[string]string) {
+	err := updateConfigmapAnnotation(cm, previousHomeDashboardsKey, func(value string) string {
+		recorded := map[string]string{}
+		if value != "" {
+			json.Unmarshal([]byte(value), &recorded)
+		}
+		for scope, uid := range previous {
+			recorded[scope] = uid
+		}
+		b, _ := json.Marshal(recorded)
+		return string(b)
+	})
+	if err != nil {
+		klog.Errorf("failed to record the previous home dashboards of %v: %v", getConfigmapName(cm), err)
+	}
+}
+
+// setHomeDashboard sets the configmap's dashboard as home dashboard for the given scopes, the
+// home dashboards it replaces are recorded on the configmap
+func setHomeDashboard(cm *corev1.ConfigMap, uid string, scopes []string) {
+	recorded := getPreviousHomeDashboards(cm)
+	replaced := map[string]string{}
+	for _, scope := range scopes {
+		unlock := lockHomeScope(scope)
+		previous, ok := putHomeDashboard(scope, uid)
+		if !ok {
+			unlock()
+			continue
+		}
+		if previous == uid {
+			// set before the loader restarted
+			previous = recorded[scope]
+		} else if recorded[scope] != previous {
+			replaced[scope] = previous
+		}
+
+		homeMutex.Lock()
+		stack, found := homeStacks[scope]
+		if !found {
+			stack = &homeStack{base: previous}
+			homeStacks[scope] = stack
+		}
+		stack.remove(uid)
+		stack.uids = append(stack.uids, uid)
+		homeMutex.Unlock()
+		unlock()
+	}
+	if len(replaced) > 0 {
+		recordPreviousHomeDashboards(cm, replaced)
+	}
+}
+
+// restoreHomeDashboard records the configmap's dashboard as home dashboard for the given scopes
+// without changing grafana, e.g. when the unchanged dashboard is skipped after a restart, so
+// that the previous home dashboards are restored when it is removed
+func restoreHomeDashboard(cm *corev1.ConfigMap, uid string, scopes []string) {
+	homeMutex.Lock()
+	defer homeMutex.Unlock()
+
+	recorded := getPreviousHomeDashboards(cm)
+	for _, scope := range scopes {
+		stack, found := homeStacks[scope]
+		if !found {
+			homeStacks[scope] = &homeStack{base: recorded[scope], uids: []string{uid}}
+			continue
+		}
+		if stack.contains(uid) {
+			continue
+		}
+		if stack.base == uid {
+			// the dashboard was replaced by the dashboards restored before
+			stack.base = recorded[scope]
+			stack.uids = append([]string{uid}, stack.uids...)
+		} else {
+			stack.uids = append(stack.uids, uid)
+		}
+	}
+}
+
+// unsetHomeDashboard restores the previous home dashboard of the given scopes, or
+// of all scopes if none is given, where the dashboard is the current home dashboard
+func unsetHomeDashboard(uid string, scopes []string) {
+	if scopes == nil {
+		homeMutex.Lock()
+		for scope := range homeStacks {
+			scopes = append(scopes, scope)
+		}
+		homeMutex.Unlock()
+	}
+
+	for _, scope := range scopes {
+		unlock := lockHomeScope(scope)
+		homeMutex.Lock()
+		stack, found := homeStacks[scope]
+		if !found {
+			homeMutex.Unlock()
+			unlock()
+			continue
+		}
+		wasHome := stack.current() == uid
+		stack.remove(uid)
+		current := stack.current()
+		if wasHome && len(stack.uids) == 0 {
+			delete(homeStacks, scope)
+		}
+		homeMutex.Unlock()
+
+		if wasHome {
+			if _, ok := putHomeDashboard(scope, current); ok {
+				klog.Infof("previous home dashboard restored for %v", scope)
+			}
+		}
+		unlock()
+	}
+}
+
+// lockHomeScope locks the changes of the home dashboard of the scope, it returns the unlock
+func lockHomeScope(scope string) func() {
+	homeMutex.Lock()
+	lock, ok := homeScopeLocks[scope]
+	if !ok {
+		lock = &sync.Mutex{}
+		homeScopeLocks[scope] = lock
+	}
+	homeMutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// unsetRemovedHomeDashboard restores the previous home dashboard for the scopes the
+// old configmap's home dashboard is no longer set for by the new configmap
+func unsetRemovedHomeDashboard(old, new interface{}) {
+	oldScopes := getHomeDashboardScopes(old)
+	if len(oldScopes) == 0 {
+		return
+	}
+	oldUID := getHomeDashboardUID(old)
+	if oldUID == "" {
+		return
+	}
+
+	newScopes := map[string]bool{}
+	if getHomeDashboardUID(new) == oldUID {
+		for _, scope := range getHomeDashboardScopes(new) {
+			newScopes[scope] = true
+		}
+	}
+	removed := []string{}
+	for _, scope := range oldScopes {
+		if !newScopes[scope] {
+			removed = append(removed, scope)
+		}
+	}
+	if len(removed) > 0 {
+		unsetHomeDashboard(oldUID, removed)
+	}
+}
+
+func getHomeDashboardUID(obj interface{}) string {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return ""
+	}
+	key := getHomeDashboardDataKey(cm)
+	if _, ok := cm.Data[key]; !ok {
+		return ""
+	}
+
+	dashboard, err := parseDashboard(cm, key)
+	if err != nil {
+		return ""
+	}
+	return dashboard["uid"].(string)
+}
+
+func (s *homeStack) current() string {
+	if len(s.uids) == 0 {
+		return s.base
+	}
+	return s.uids[len(s.uids)-1]
+}
+
+func (s *homeStack) contains(uid string) bool {
+	for _, u := range s.uids {
+		if u == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *homeStack) remove(uid string) {
+	uids := []string{}
+	for _, u := range s.uids {
+		if u != uid {
+			uids = append(uids, u)
+		}
+	}
+	s.uids = uids
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+func TestGetHomeDashboardScopes(t *testing.T) {
+	testCaseList := []struct {
+		name     string
+		cm       *corev1.ConfigMap
+		expected []string
+	}{
+
+		{
+			"invalid cm",
+			nil,
+			nil,
+		},
+
+		{
+			"no annotation",
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "test",
+				},
+			},
+			[]string{},
+		},
+
+		{
+			"org and teams",
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "test",
+					Annotations: map[string]string{homeDashboardKey: "true, team:sre,invalid"},
+				},
+			},
+			[]string{"org", "team:sre"},
+		},
+
+		{
+			"users",
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "test",
+					Annotations: map[string]string{homeDashboardKey: "user:alice,org"},
+				},
+			},
+			[]string{"user:alice", "org"},
+		},
+	}
+
+	for _, c := range testCaseList {
+		output := getHomeDashboardScopes(c.cm)
+		if !reflect.DeepEqual(output, c.expected) {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
+
+func TestGetHomeDashboardDataKey(t *testing.T) {
+	testCaseList := []struct {
+		name     string
+		cm       *corev1.ConfigMap
+		expected string
+	}{
+
+		{
+			"invalid cm",
+			nil,
+			"",
+		},
+
+		{
+			"first key",
+			&corev1.ConfigMap{
+				Data: map[string]string{"b.json": "{}", "a.json": "{}"},
+			},
+			"a.json",
+		},
+
+		{
+			"annotated key",
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{homeDashboardDataKey: "b.json"},
+				},
+				Data: map[string]string{"b.json": "{}", "a.json": "{}"},
+			},
+			"b.json",
+		},
+	}
+
+	for _, c := range testCaseList {
+		output := getHomeDashboardDataKey(c.cm)
+		if output != c.expected {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
+
+func TestSetHomeDashboard(t *testing.T) {
+	startFakeServer(t)
+	homeStacks = map[string]*homeStack{}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"}}
+
+	setHomeDashboard(cm, "first", []string{"org", "team:sre", "team:unknown", "user:alice", "user:unknown"})
+	setHomeDashboard(cm, "second", []string{"org"})
+	if _, ok := homeStacks["team:unknown"]; ok {
+		t.Errorf("home dashboard should not be recorded for unknown team")
+	}
+	if _, ok := homeStacks["user:unknown"]; ok {
+		t.Errorf("home dashboard should not be recorded for unknown user")
+	}
+	if homeStacks["user:alice"].current() != "first" {
+		t.Errorf("unexpected user home dashboards: %v", homeStacks["user:alice"])
+	}
+	if homeStacks["org"].base != "previous" || homeStacks["org"].current() != "second" {
+		t.Errorf("unexpected org home dashboards: %v", homeStacks["org"])
+	}
+
+	unsetHomeDashboard("first", nil)
+	if homeStacks["org"].current() != "second" {
+		t.Errorf("home dashboard should not change when an older one is removed")
+	}
+	if _, ok := homeStacks["team:sre"]; ok {
+		t.Errorf("team home dashboard should be restored")
+	}
+	if _, ok := homeStacks["user:alice"]; ok {
+		t.Errorf("user home dashboard should be restored")
+	}
+
+	unsetHomeDashboard("second", []string{"org"})
+	if _, ok := homeStacks["org"]; ok {
+		t.Errorf("org home dashboard should be restored")
+	}
+}
+
+func TestRecordPreviousHomeDashboards(t *testing.T) {
+	startFakeServer(t)
+	homeStacks = map[string]*homeStack{}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns", UID: "1"}}
+	configmapClient = fake.NewSimpleClientset(cm).CoreV1()
+	defer func() { configmapClient = nil }()
+
+	setHomeDashboard(cm, "first", []string{"org"})
+	latest, _ := configmapClient.ConfigMaps("ns").Get(context.TODO(), "test", metav1.GetOptions{})
+	if latest.Annotations[previousHomeDashboardsKey] != `{"org":"previous"}` {
+		t.Errorf("the annotations %v are not the expected", latest.Annotations)
+	}
+}
+
+func TestRestoreHomeDashboard(t *testing.T) {
+	homeStacks = map[string]*homeStack{}
+	first := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:        "first",
+		Annotations: map[string]string{previousHomeDashboardsKey: `{"org":"previous"}`},
+	}}
+	second := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:        "second",
+		Annotations: map[string]string{previousHomeDashboardsKey: `{"org":"first"}`},
+	}}
+
+	// the dashboards are restored after a restart in any order
+	restoreHomeDashboard(second, "second", []string{"org"})
+	restoreHomeDashboard(first, "first", []string{"org"})
+	restoreHomeDashboard(first, "first", []string{"org"})
+	expected := &homeStack{base: "previous", uids: []string{"first", "second"}}
+	if !reflect.DeepEqual(homeStacks["org"], expected) {
+		t.Errorf("the org home dashboards %v are not the expected %v", homeStacks["org"], expected)
+	}
+}
+
+func TestGetPreferencesUser(t *testing.T) {
+	impersonated := []string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/users/lookup", func(w http.ResponseWriter, req *http.Request) {
+		impersonated = append(impersonated, req.Header.Get("X-Forwarded-User"))
+		switch req.URL.Query().Get("loginOrEmail") {
+		case "alice":
+			w.Write([]byte(`{"id": 2, "login": "alice"}`))
+		case "alice@example.com":
+			w.Write([]byte(`{"id": 2, "login": "alice", "email": "alice@example.com"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	startGrafanaServer(t, mux)
+
+	testCaseList := []struct {
+		name     string
+		scope    string
+		expected string
+		ok       bool
+	}{
+		{"org", "org", util.GetGrafanaUser(), true},
+		{"team", "team:sre", util.GetGrafanaUser(), true},
+		{"user", "user:alice", "alice", true},
+		{"email", "user:alice@example.com", "", false},
+		{"unknown user", "user:bob", "", false},
+	}
+
+	for _, c := range testCaseList {
+		output, ok := getPreferencesUser(c.scope)
+		if output != c.expected || ok != c.ok {
+			t.Errorf("case (%v) output: (%v, %v) is not the expected: (%v, %v)", c.name, output, ok, c.expected, c.ok)
+		}
+	}
+	// the users are looked up as the admin, so that unknown logins are not signed up
+	for _, user := range impersonated {
+		if user != util.GetGrafanaUser() {
+			t.Errorf("the users should be looked up as the admin instead of %v", user)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// dashboardInputsKey sets the inputs of dashboards exported for sharing as a comma
+// separated list, e.g. "DS_PROMETHEUS=Observatorium,VAR_ENV=prod"
+const dashboardInputsKey = "observability.open-cluster-management.io/dashboard-inputs"
+
+var (
+	// DefaultDatasource is the datasource which satisfies the datasource inputs of shared dashboards
+	DefaultDatasource = ""
+	// exportFields are set by grafana when exporting dashboards for sharing
+	exportFields = []string{"__inputs", "__requires", "__elements"}
+)
+
+// getInputMapping returns the input values of the configmap annotation by input name
+func getInputMapping(cm *corev1.ConfigMap) (map[string]string, error) {
+	mapping := map[string]string{}
+	if cm == nil {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(cm.ObjectMeta.Annotations[dashboardInputsKey], ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid input %v in annotation %v", pair, dashboardInputsKey)
+		}
+		mapping[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return mapping, nil
+}
+
+// getInputValues returns the values of the __inputs of the dashboard by input name, from
+// the mapping, the default datasource or the constant value
+func getInputValues(dashboard map[string]interface{}, mapping map[string]string) (map[string]string, error) {
+	inputs, _ := dashboard["__inputs"].([]interface{})
+	values := map[string]string{}
+	for _, i := range inputs {
+		input, _ := i.(map[string]interface{})
+		name, _ := input["name"].(string)
+		if name == "" {
+			continue
+		}
+		if value, ok := mapping[name]; ok {
+			values[name] = value
+			continue
+		}
+
+		switch input["type"] {
+		case "datasource":
+			if DefaultDatasource == "" {
+				return nil, fmt.Errorf("no datasource for input %v of plugin %v, set it in annotation %v",
+					name, input["pluginId"], dashboardInputsKey)
+			}
+			values[name] = DefaultDatasource
+		case "constant":
+			values[name] = fmt.Sprint(input["value"])
+		default:
+			return nil, fmt.Errorf("no value for input %v of type %v, set it in annotation %v",
+				name, input["type"], dashboardInputsKey)
+		}
+	}
+	return values, nil
+}
+
+// substituteInputs replaces the ${NAME} placeholders of the inputs in the strings of the value
+func substituteInputs(value interface{}, replacer *strings.Replacer) interface{} {
+	switch v := value.(type) {
+	case string:
+		return replacer.Replace(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = substituteInputs(item, replacer)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = substituteInputs(item, replacer)
+		}
+	}
+	return value
+}
+
+// applyDashboardInputs substitutes the __inputs of a dashboard exported for sharing and
+// strips the export fields
+func applyDashboardInputs(cm *corev1.ConfigMap, dashboard map[string]interface{}) error {
+	values := map[string]string{}
+	if _, ok := dashboard["__inputs"]; ok {
+		mapping, err := getInputMapping(cm)
+		if err != nil {
+			return err
+		}
+		values, err = getInputValues(dashboard, mapping)
+		if err != nil {
+			return err
+		}
+	}
+	for _, field := range exportFields {
+		delete(dashboard, field)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	pairs := []string{}
+	for name, value := range values {
+		pairs = append(pairs, "${"+name+"}", value)
+	}
+	substituteInputs(dashboard, strings.NewReplacer(pairs...))
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetInputMapping(t *testing.T) {
+	testCaseList := []struct {
+		name       string
+		annotation string
+		expected   map[string]string
+	}{
+		{"none", "", map[string]string{}},
+		{"pairs", "DS_PROMETHEUS=Observatorium, VAR_ENV = prod", map[string]string{"DS_PROMETHEUS": "Observatorium", "VAR_ENV": "prod"}},
+		{"invalid", "DS_PROMETHEUS", nil},
+	}
+
+	for _, c := range testCaseList {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{dashboardInputsKey: c.annotation}},
+		}
+		output, _ := getInputMapping(cm)
+		if !reflect.DeepEqual(output, c.expected) {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
+
+func TestApplyDashboardInputs(t *testing.T) {
+	testCaseList := []struct {
+		name       string
+		annotation string
+		defaultDS  string
+		data       string
+		expected   string
+		ok         bool
+	}{
+		{"no inputs", "", "", `{"title": "${DS_PROMETHEUS}", "__requires": []}`, "${DS_PROMETHEUS}", true},
+		{"default datasource", "", "Observatorium", `{"__inputs": [{"name": "DS_PROMETHEUS", "type": "datasource"}], "title": "${DS_PROMETHEUS}"}`, "Observatorium", true},
+		{"mapped datasource", "DS_PROMETHEUS=Thanos", "Observatorium", `{"__inputs": [{"name": "DS_PROMETHEUS", "type": "datasource"}], "title": "${DS_PROMETHEUS}"}`, "Thanos", true},
+		{"missing datasource", "", "", `{"__inputs": [{"name": "DS_PROMETHEUS", "type": "datasource"}], "title": "${DS_PROMETHEUS}"}`, "", false},
+		{"constant", "", "", `{"__inputs": [{"name": "VAR_ENV", "type": "constant", "value": "prod"}], "title": "env ${VAR_ENV}"}`, "env prod", true},
+		{"mapped constant", "VAR_ENV=dev", "", `{"__inputs": [{"name": "VAR_ENV", "type": "constant", "value": "prod"}], "title": "env ${VAR_ENV}"}`, "env dev", true},
+		{"unsupported", "", "", `{"__inputs": [{"name": "X", "type": "panel"}], "title": "${X}"}`, "", false},
+	}
+
+	defer func() { DefaultDatasource = "" }()
+	for _, c := range testCaseList {
+		DefaultDatasource = c.defaultDS
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{dashboardInputsKey: c.annotation}},
+		}
+		dashboard := map[string]interface{}{}
+		json.Unmarshal([]byte(c.data), &dashboard)
+		err := applyDashboardInputs(cm, dashboard)
+		if (err == nil) != c.ok {
+			t.Errorf("case (%v) error: (%v) is not the expected", c.name, err)
+			continue
+		}
+		if !c.ok {
+			continue
+		}
+		if dashboard["title"] != c.expected || dashboard["__inputs"] != nil || dashboard["__requires"] != nil {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, dashboard, c.expected)
+		}
+	}
+}
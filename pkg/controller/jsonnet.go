@@ -0,0 +1,96 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/go-jsonnet"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	jsonnetExt        = ".jsonnet"
+	jsonnetLibraryExt = ".libsonnet"
+	// jsonnetExtVarPrefix prefixes the annotations passed to jsonnet as external variables,
+	// e.g. jsonnet.observability.open-cluster-management.io/cluster: hub
+	jsonnetExtVarPrefix = "jsonnet.observability.open-cluster-management.io/"
+)
+
+func isJsonnetKey(key string) bool {
+	return strings.HasSuffix(key, jsonnetExt)
+}
+
+// isJsonnetLibraryKey returns whether the data key is a library imported by jsonnet dashboards
+func isJsonnetLibraryKey(key string) bool {
+	return strings.HasSuffix(key, jsonnetLibraryExt)
+}
+
+// configmapImporter imports the keys of the configmap, or the keys of the other configmaps
+// of its namespace as NAME/KEY
+type configmapImporter struct {
+	cm    *corev1.ConfigMap
+	cache map[string]jsonnet.Contents
+}
+
+// Import implements jsonnet.Importer, imports without a configmap are searched in the
+// configmap of the importing file first
+func (i *configmapImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	candidates := []string{importedPath}
+	if !strings.Contains(importedPath, "/") {
+		candidates = []string{path.Join(path.Dir(importedFrom), importedPath), i.cm.GetName() + "/" + importedPath}
+	}
+
+	for _, foundAt := range candidates {
+		if contents, ok := i.cache[foundAt]; ok {
+			return contents, foundAt, nil
+		}
+		parts := strings.SplitN(foundAt, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		cm := i.cm
+		if parts[0] != i.cm.GetName() {
+			var err error
+			cm, err = lookupConfigmap(i.cm.GetNamespace(), parts[0])
+			if err != nil {
+				continue
+			}
+		}
+		data, ok := cm.Data[parts[1]]
+		if !ok {
+			continue
+		}
+		contents := jsonnet.MakeContents(data)
+		i.cache[foundAt] = contents
+		return contents, foundAt, nil
+	}
+	return jsonnet.Contents{}, "", fmt.Errorf("import %v not found in configmap %v", importedPath, i.cm.GetName())
+}
+
+// getJsonnetExtVars returns the external variables of the configmap annotations
+func getJsonnetExtVars(cm *corev1.ConfigMap) map[string]string {
+	extVars := map[string]string{}
+	for key, value := range cm.ObjectMeta.Annotations {
+		if strings.HasPrefix(key, jsonnetExtVarPrefix) {
+			extVars[strings.TrimPrefix(key, jsonnetExtVarPrefix)] = value
+		}
+	}
+	return extVars
+}
+
+// renderJsonnet evaluates the jsonnet snippet of the configmap's data key into dashboard JSON
+func renderJsonnet(cm *corev1.ConfigMap, key, snippet string) (string, error) {
+	vm := jsonnet.MakeVM()
+	vm.Importer(&configmapImporter{cm: cm, cache: map[string]jsonnet.Contents{}})
+
+	for name, value := range getJsonnetExtVars(cm) {
+		vm.ExtVar(name, value)
+	}
+
+	return vm.EvaluateAnonymousSnippet(cm.GetName()+"/"+key, snippet)
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderJsonnet(t *testing.T) {
+	grafonnet := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "grafonnet", Namespace: "jsonnet-test"},
+		Data: map[string]string{
+			"grafana.libsonnet":   `{ dashboard: import 'dashboard.libsonnet' }`,
+			"dashboard.libsonnet": `{ new(title):: { title: title, panels: [] } }`,
+		},
+	}
+	addConfigmapLookup(func(namespace, name string) *corev1.ConfigMap {
+		if namespace == grafonnet.GetNamespace() && name == grafonnet.GetName() {
+			return grafonnet
+		}
+		return nil
+	})
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dashboards",
+			Namespace:   "jsonnet-test",
+			Annotations: map[string]string{jsonnetExtVarPrefix + "cluster": "hub"},
+		},
+		Data: map[string]string{
+			"tags.libsonnet":  `['acm']`,
+			"hub.jsonnet":     `local g = import 'grafonnet/grafana.libsonnet'; g.dashboard.new(std.extVar('cluster')) + { tags: import 'tags.libsonnet' }`,
+			"missing.jsonnet": `import 'missing/lib.libsonnet'`,
+			"plain.json":      `{"title": "Plain"}`,
+		},
+	}
+
+	if keys := getDashboardKeys(cm); !reflect.DeepEqual(keys, []string{"hub.jsonnet", "missing.jsonnet", "plain.json"}) {
+		t.Errorf("the dashboard keys %v should not contain the libraries", keys)
+	}
+
+	dashboard, err := parseDashboard(cm, "hub.jsonnet")
+	if err != nil {
+		t.Fatalf("fail to render jsonnet with %v", err)
+	}
+	if dashboard["title"] != "hub" || !reflect.DeepEqual(dashboard["tags"], []interface{}{"acm"}) || dashboard["uid"] == "" {
+		t.Errorf("the dashboard %v is not the expected", dashboard)
+	}
+
+	_, err = parseDashboard(cm, "missing.jsonnet")
+	if err == nil {
+		t.Errorf("rendering a missing import should fail")
+	}
+}
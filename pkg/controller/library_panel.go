@@ -0,0 +1,386 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+const (
+	libraryPanelLabel = "grafana-custom-library-panel"
+	// libraryPanelKind is the kind of library elements which are panels
+	libraryPanelKind = 1
+)
+
+var (
+	// libraryPanelRefs records the library panels referenced by each managed dashboard
+	libraryPanelRefs = map[string][]string{}
+	// pendingLibraryPanels are library panels whose configmap is deleted but which
+	// are still referenced by managed dashboards
+	pendingLibraryPanels = map[string]bool{}
+	libraryMutex         sync.Mutex
+)
+
+func isLibraryPanelConfigmap(obj interface{}) bool {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return false
+	}
+
+	return strings.ToLower(cm.ObjectMeta.Labels[libraryPanelLabel]) == "true"
+}
+
+// getLibraryPanelUID returns the uid of the library panel, generated from the configmap
+// and data key if the panel model does not set one
+func getLibraryPanelUID(cm *corev1.ConfigMap, key string, model map[string]interface{}) string {
+	if uid, ok := model["uid"].(string); ok && uid != "" {
+		return uid
+	}
+	name := strings.NewReplacer(".", "-", "_", "-").Replace(strings.TrimSuffix(key, ".json"))
+	uid, _ := util.GenerateUID(cm.GetName()+"-"+name, cm.GetNamespace())
+	return uid
+}
+
+// getLibraryPanelRefs returns the uids of the library panels used by the dashboard
+func getLibraryPanelRefs(dashboard map[string]interface{}) []string {
+	refs := map[string]bool{}
+	var walk func(panels interface{})
+	walk = func(panels interface{}) {
+		list, ok := panels.([]interface{})
+		if !ok {
+			return
+		}
+		for _, p := range list {
+			panel, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if libraryPanel, ok := panel["libraryPanel"].(map[string]interface{}); ok {
+				if uid, ok := libraryPanel["uid"].(string); ok && uid != "" {
+					refs[uid] = true
+				}
+			}
+			// collapsed rows keep their panels inside the row
+			walk(panel["panels"])
+		}
+	}
+	walk(dashboard["panels"])
+
+	uids := []string{}
+	for uid := range refs {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	return uids
+}
+
+// recordLibraryPanelRefs records the library panels referenced by the managed dashboard
+func recordLibraryPanelRefs(dashboardUID string, refs []string) {
+	libraryMutex.Lock()
+	if len(refs) == 0 {
+		delete(libraryPanelRefs, dashboardUID)
+	} else {
+		libraryPanelRefs[dashboardUID] = refs
+	}
+	uids := getUnreferencedLibraryPanels()
+	libraryMutex.Unlock()
+
+	deletePendingLibraryPanels(uids)
+}
+
+// releaseLibraryPanelRefs forgets the library panels referenced by the deleted dashboard
+// and deletes the pending library panels which are no longer referenced
+func releaseLibraryPanelRefs(dashboardUID string) {
+	libraryMutex.Lock()
+	delete(libraryPanelRefs, dashboardUID)
+	uids := getUnreferencedLibraryPanels()
+	libraryMutex.Unlock()
+
+	deletePendingLibraryPanels(uids)
+}
+
+func isLibraryPanelReferenced(uid string) bool {
+	for _, refs := range libraryPanelRefs {
+		for _, ref := range refs {
+			if ref == uid {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getUnreferencedLibraryPanels returns the pending library panels which no managed dashboard
+// references, the caller holds libraryMutex
+func getUnreferencedLibraryPanels() []string {
+	uids := []string{}
+	for uid := range pendingLibraryPanels {
+		if !isLibraryPanelReferenced(uid) {
+			uids = append(uids, uid)
+		}
+	}
+	sort.Strings(uids)
+	return uids
+}
+
+// deletePendingLibraryPanels deletes the pending library panels without holding libraryMutex,
+// the panels still connected to dashboards in grafana stay pending, e.g. after a restart when
+// the dashboards referencing them are not loaded again yet
+func deletePendingLibraryPanels(uids []string) {
+	for _, uid := range uids {
+		libraryMutex.Lock()
+		pending := pendingLibraryPanels[uid] && !isLibraryPanelReferenced(uid)
+		libraryMutex.Unlock()
+		if !pending {
+			continue
+		}
+		if isLibraryPanelConnected(uid) {
+			klog.Infof("library panel %v is still used by dashboards in grafana", uid)
+			continue
+		}
+		if deleteLibraryPanel(uid) {
+			libraryMutex.Lock()
+			delete(pendingLibraryPanels, uid)
+			libraryMutex.Unlock()
+		}
+	}
+}
+
+// isLibraryPanelConnected returns whether dashboards in grafana use the library panel, or
+// whether it is unknown, grafana keeps the connections across restarts of the loader
+func isLibraryPanelConnected(uid string) bool {
+	grafanaURL := grafanaURI + "/api/library-elements/" + uid + "/connections"
+	body, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	if respStatusCode == http.StatusNotFound {
+		return false
+	}
+	if respStatusCode != http.StatusOK {
+		klog.Errorf("failed to get connections of library panel %v with %v", uid, respStatusCode)
+		return true
+	}
+
+	connections := struct {
+		Result []interface{} `json:"result"`
+	}{}
+	err := json.Unmarshal(body, &connections)
+	if err != nil {
+		klog.Error(unmarshallErrMsg, "error", err)
+		return true
+	}
+	return len(connections.Result) > 0
+}
+
+// getLibraryPanelVersion returns the version of the existing library panel, or 0 if it does not exist
+func getLibraryPanelVersion(uid string) float64 {
+	grafanaURL := grafanaURI + "/api/library-elements/" + uid
+	body, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK {
+		return 0
+	}
+
+	element := struct {
+		Result map[string]interface{} `json:"result"`
+	}{}
+	err := json.Unmarshal(body, &element)
+	if err != nil {
+		klog.Error(unmarshallErrMsg, "error", err)
+		return 0
+	}
+	version, _ := element.Result["version"].(float64)
+	return version
+}
+
+// getLibraryPanelUIDs returns the uids of the library panels in the configmap
+func getLibraryPanelUIDs(obj interface{}) []string {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return nil
+	}
+
+	uids := []string{}
+	for key, value := range cm.Data {
+		model := map[string]interface{}{}
+		err := json.Unmarshal([]byte(value), &model)
+		if err != nil {
+			klog.Error("Failed to unmarshall data", "error", err)
+			continue
+		}
+		uids = append(uids, getLibraryPanelUID(cm, key, model))
+	}
+	return uids
+}
+
+// updateLibraryPanels is used to create or update the library panels via calling grafana api
+func updateLibraryPanels(old, new interface{}) {
+	postLibraryPanels(new.(*corev1.ConfigMap))
+
+	// delete the library panels removed from the configmap
+	current := map[string]bool{}
+	for _, uid := range getLibraryPanelUIDs(new) {
+		current[uid] = true
+	}
+	removed := []string{}
+	for _, uid := range getLibraryPanelUIDs(old) {
+		if !current[uid] {
+			removed = append(removed, uid)
+		}
+	}
+	removeLibraryPanels(removed)
+}
+
+// postLibraryPanels creates or updates the library panels of the configmap in its folder, the
+// folder is not deleted meanwhile
+func postLibraryPanels(cm *corev1.ConfigMap) {
+	folderDeletionMutex.RLock()
+	defer folderDeletionMutex.RUnlock()
+
+	folderUID := ""
+	folderTitle := getDashboardCustomFolderTitle(cm)
+	if folderTitle != "" {
+		folderID := createCustomFolder(folderTitle)
+		if folderID == 0 {
+			klog.Error("Failed to get custom folder id")
+			return
+		}
+		folderUID = getCustomFolderUID(folderID)
+	}
+
+	for key, value := range cm.Data {
+		model := map[string]interface{}{}
+		err := json.Unmarshal([]byte(value), &model)
+		if err != nil {
+			klog.Error("Failed to unmarshall data", "error", err)
+			continue
+		}
+
+		uid := getLibraryPanelUID(cm, key, model)
+		name, ok := model["title"].(string)
+		if !ok || name == "" {
+			name = key
+		}
+		delete(model, "id")
+		delete(model, "libraryPanel")
+		data := map[string]interface{}{
+			"uid":       uid,
+			"folderUid": folderUID,
+			"name":      name,
+			"model":     model,
+			"kind":      libraryPanelKind,
+		}
+
+		method := "POST"
+		grafanaURL := grafanaURI + "/api/library-elements"
+		if version := getLibraryPanelVersion(uid); version != 0 {
+			method = "PATCH"
+			grafanaURL = grafanaURL + "/" + uid
+			data["version"] = version
+		}
+
+		b, err := json.Marshal(data)
+		if err != nil {
+			klog.Error("failed to marshal body", "error", err)
+			continue
+		}
+		_, respStatusCode := util.SetRequest(method, grafanaURL, bytes.NewBuffer(b), retry)
+		if respStatusCode != http.StatusOK {
+			klog.Infof("failed to create/update library panel %v: %v", uid, respStatusCode)
+			continue
+		}
+
+		libraryMutex.Lock()
+		delete(pendingLibraryPanels, uid)
+		libraryMutex.Unlock()
+		klog.Infof("Library panel %v created/updated", uid)
+	}
+}
+
+// deleteLibraryPanels is used to delete the library panels of the configmap via calling grafana api
+func deleteLibraryPanels(obj interface{}) {
+	removeLibraryPanels(getLibraryPanelUIDs(obj))
+}
+
+// removeLibraryPanels deletes the library panels which are not referenced by managed
+// dashboards, the others are deleted once they are no longer referenced
+func removeLibraryPanels(uids []string) {
+	libraryMutex.Lock()
+	unreferenced := []string{}
+	for _, uid := range uids {
+		pendingLibraryPanels[uid] = true
+		if isLibraryPanelReferenced(uid) {
+			klog.Infof("library panel %v is still referenced by managed dashboards", uid)
+			continue
+		}
+		unreferenced = append(unreferenced, uid)
+	}
+	libraryMutex.Unlock()
+
+	deletePendingLibraryPanels(unreferenced)
+}
+
+func deleteLibraryPanel(uid string) bool {
+	grafanaURL := grafanaURI + "/api/library-elements/" + uid
+	_, respStatusCode := util.SetRequest("DELETE", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK && respStatusCode != http.StatusNotFound {
+		klog.Errorf("failed to delete library panel %v with %v", uid, respStatusCode)
+		return false
+	}
+
+	klog.Infof("Library panel %v deleted", uid)
+	return true
+}
+
+// syncLibraryPanels loads the library panels before the dashboards so that the
+// dashboards referencing them resolve on first load
+func syncLibraryPanels(coreClient corev1client.CoreV1Interface) {
+	watchedNS := os.Getenv("POD_NAMESPACE")
+	cms, err := coreClient.ConfigMaps(watchedNS).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		klog.Error("Failed to list library panel configmaps", "error", err)
+		return
+	}
+
+	for i := range cms.Items {
+		if isLibraryPanelConfigmap(&cms.Items[i]) {
+			updateLibraryPanels(nil, &cms.Items[i])
+		}
+	}
+}
+
+// hasFolderLibraryElements returns whether library panels or variables are in the folder, or
+// whether it is unknown
+func hasFolderLibraryElements(folderID float64) bool {
+	grafanaURL := grafanaURI + "/api/library-elements?perPage=1&folderFilter=" + fmt.Sprint(folderID)
+	body, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK {
+		klog.Errorf("failed to get library elements of folder %v with %v", folderID, respStatusCode)
+		return true
+	}
+
+	result := struct {
+		Result struct {
+			TotalCount float64 `json:"totalCount"`
+		} `json:"result"`
+	}{}
+	err := json.Unmarshal(body, &result)
+	if err != nil {
+		klog.Error(unmarshallErrMsg, "error", err)
+		return true
+	}
+	return result.Result.TotalCount > 0
+}
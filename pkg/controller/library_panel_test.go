@@ -0,0 +1,139 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsLibraryPanelConfigmap(t *testing.T) {
+	testCaseList := []struct {
+		name     string
+		cm       *corev1.ConfigMap
+		expected bool
+	}{
+
+		{
+			"invalid cm",
+			nil,
+			false,
+		},
+
+		{
+			"valid label",
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: map[string]string{libraryPanelLabel: "true"},
+				},
+			},
+			true,
+		},
+
+		{
+			"dashboard label",
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: map[string]string{"grafana-custom-dashboard": "true"},
+				},
+			},
+			false,
+		},
+	}
+
+	for _, c := range testCaseList {
+		output := isLibraryPanelConfigmap(c.cm)
+		if output != c.expected {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
+
+func TestGetLibraryPanelUID(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "panels",
+			Namespace: "ns",
+		},
+	}
+
+	uid := getLibraryPanelUID(cm, "cpu_usage.json", map[string]interface{}{})
+	if uid != "panels-cpu-usage-ns" {
+		t.Errorf("the uid %v is not the expected panels-cpu-usage-ns", uid)
+	}
+
+	uid = getLibraryPanelUID(cm, "cpu_usage.json", map[string]interface{}{"uid": "cpu"})
+	if uid != "cpu" {
+		t.Errorf("the uid %v is not the expected cpu", uid)
+	}
+}
+
+func TestGetLibraryPanelRefs(t *testing.T) {
+	dashboard := map[string]interface{}{}
+	err := json.Unmarshal([]byte(`{
+		"panels": [
+			{"id": 1, "libraryPanel": {"uid": "b", "name": "B"}},
+			{"id": 2, "type": "row", "panels": [
+				{"id": 3, "libraryPanel": {"uid": "a", "name": "A"}},
+				{"id": 4, "libraryPanel": {"uid": "b", "name": "B"}}
+			]},
+			{"id": 5, "type": "graph"}
+		]
+	}`), &dashboard)
+	if err != nil {
+		t.Fatalf("failed to unmarshal dashboard: %v", err)
+	}
+
+	refs := getLibraryPanelRefs(dashboard)
+	if !reflect.DeepEqual(refs, []string{"a", "b"}) {
+		t.Errorf("the refs %v are not the expected [a b]", refs)
+	}
+}
+
+func TestRemoveLibraryPanels(t *testing.T) {
+	startFakeServer(t)
+	libraryPanelRefs = map[string][]string{}
+	pendingLibraryPanels = map[string]bool{}
+
+	recordLibraryPanelRefs("dashboard", []string{"existing"})
+	removeLibraryPanels([]string{"existing", "unused"})
+	if !pendingLibraryPanels["existing"] || pendingLibraryPanels["unused"] {
+		t.Errorf("only the referenced library panel should be pending: %v", pendingLibraryPanels)
+	}
+
+	releaseLibraryPanelRefs("dashboard")
+	if len(pendingLibraryPanels) != 0 {
+		t.Errorf("the library panel should be deleted once no longer referenced: %v", pendingLibraryPanels)
+	}
+
+	// the references are unknown after a restart, the panel used in grafana is kept
+	removeLibraryPanels([]string{"connected"})
+	if !pendingLibraryPanels["connected"] {
+		t.Errorf("the library panel used by dashboards in grafana should be pending: %v", pendingLibraryPanels)
+	}
+}
+
+func TestUpdateLibraryPanels(t *testing.T) {
+	startFakeServer(t)
+	pendingLibraryPanels = map[string]bool{"existing": true}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "panels",
+			Namespace: "ns",
+			Labels:    map[string]string{libraryPanelLabel: "true"},
+		},
+		Data: map[string]string{"cpu.json": `{"uid": "existing", "title": "CPU", "type": "graph"}`},
+	}
+	updateLibraryPanels(nil, cm)
+	if pendingLibraryPanels["existing"] {
+		t.Errorf("the library panel should not be pending after it is updated")
+	}
+}
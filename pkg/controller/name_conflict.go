@@ -0,0 +1,226 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+const (
+	// nameConflictKey is the annotation choosing how a dashboard is loaded when another
+	// dashboard with the same title exists in its folder
+	nameConflictKey = "observability.open-cluster-management.io/dashboard-name-conflict"
+
+	// nameConflictFail reports the conflict and does not load the dashboard
+	nameConflictFail = "fail"
+	// nameConflictAdopt takes over the existing dashboard with its uid, id and version history
+	nameConflictAdopt = "adopt"
+	// nameConflictRename loads the dashboard with the configmap name appended to the title
+	nameConflictRename = "rename"
+	// nameConflictMove moves the existing dashboard into the movedDashboardsFolder
+	nameConflictMove = "move"
+
+	movedDashboardsFolder = "Moved dashboards"
+
+	// adoptedDashboardsKey is the annotation recording the uids of the adopted dashboards by
+	// data key as a json object, the dashboards of the keys are loaded with these uids
+	adoptedDashboardsKey = "observability.open-cluster-management.io/adopted-dashboards"
+)
+
+func isValidNameConflictPolicy(policy string) bool {
+	switch policy {
+	case nameConflictFail, nameConflictAdopt, nameConflictRename, nameConflictMove:
+		return true
+	}
+	return false
+}
+
+// getNameConflictPolicy returns the name conflict policy of the configmap, fail by default
+func getNameConflictPolicy(cm *corev1.ConfigMap) string {
+	policy, ok := cm.Annotations[nameConflictKey]
+	if !ok {
+		return nameConflictFail
+	}
+	if !isValidNameConflictPolicy(policy) {
+		klog.Warningf("invalid name conflict policy %v of %v, use %v", policy, getConfigmapName(cm), nameConflictFail)
+		return nameConflictFail
+	}
+	return policy
+}
+
+// findDashboardByTitle returns the search result of the dashboard with the title in the folder
+func findDashboardByTitle(folderID float64, title string) (map[string]interface{}, bool) {
+	query := url.Values{}
+	query.Set("type", "dash-db")
+	query.Set("query", title)
+	query.Set("folderIds", fmt.Sprint(folderID))
+	grafanaURL := grafanaURI + "/api/search?" + query.Encode()
+	body, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK {
+		klog.Errorf("failed to search dashboard %v with %v", title, respStatusCode)
+		return nil, false
+	}
+
+	results := []map[string]interface{}{}
+	err := json.Unmarshal(body, &results)
+	if err != nil {
+		klog.Error(unmarshallErrMsg, "error", err)
+		return nil, false
+	}
+	for _, result := range results {
+		// grafana compares the titles case insensitively
+		if found, _ := result["title"].(string); strings.EqualFold(found, title) {
+			return result, true
+		}
+	}
+	return nil, false
+}
+
+func postDashboardData(data map[string]interface{}) ([]byte, int, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	body, respStatusCode := util.SetRequest("POST", grafanaURI+"/api/dashboards/db", bytes.NewBuffer(b), retry)
+	return body, respStatusCode, nil
+}
+
+// getAdoptedDashboardUIDs returns the uids of the dashboards adopted by the data keys
+func getAdoptedDashboardUIDs(cm *corev1.ConfigMap) map[string]string {
+	uids := map[string]string{}
+	value, ok := cm.Annotations[adoptedDashboardsKey]
+	if !ok {
+		return uids
+	}
+	err := json.Unmarshal([]byte(value), &uids)
+	if err != nil {
+		klog.Warningf("invalid %v annotation of %v: %v", adoptedDashboardsKey, getConfigmapName(cm), err)
+		return map[string]string{}
+	}
+	return uids
+}
+
+// recordAdoptedDashboard records the uid of the dashboard adopted by the data key on the configmap
+func recordAdoptedDashboard(cm *corev1.ConfigMap, key, uid string) error {
+	return updateConfigmapAnnotation(cm, adoptedDashboardsKey, func(value string) string {
+		uids := map[string]string{}
+		if value != "" {
+			json.Unmarshal([]byte(value), &uids)
+		}
+		uids[key] = uid
+		b, _ := json.Marshal(uids)
+		return string(b)
+	})
+}
+
+// getResponseUID returns the uid of the saved dashboard in the response, or the uid if unknown
+func getResponseUID(body []byte, uid string) string {
+	resp := map[string]interface{}{}
+	if json.Unmarshal(body, &resp) != nil {
+		return uid
+	}
+	if saved, ok := resp["uid"].(string); ok && saved != "" {
+		return saved
+	}
+	return uid
+}
+
+// resolveNameConflict loads the dashboard of the data key according to the name conflict policy
+// of the configmap, when another dashboard with the same title exists in the folder
+func resolveNameConflict(cm *corev1.ConfigMap, key string, data map[string]interface{}) ([]byte, int, error) {
+	dashboard := data["dashboard"].(map[string]interface{})
+	title, _ := dashboard["title"].(string)
+	folderID, _ := data["folderId"].(float64)
+	policy := getNameConflictPolicy(cm)
+	if policy == nameConflictFail {
+		return nil, 0, fmt.Errorf("a dashboard titled %v already exists in the folder", title)
+	}
+
+	existing, found := findDashboardByTitle(folderID, title)
+	if !found {
+		return nil, 0, fmt.Errorf("a dashboard titled %v already exists in the folder but cannot be found", title)
+	}
+	existingUID, _ := existing["uid"].(string)
+
+	resolved := map[string]interface{}{}
+	for k, v := range data {
+		resolved[k] = v
+	}
+	loaded := map[string]interface{}{}
+	for k, v := range dashboard {
+		loaded[k] = v
+	}
+	resolved["dashboard"] = loaded
+
+	var message string
+	switch policy {
+	case nameConflictAdopt:
+		// the dashboard keeps its uid, so that links to it keep working
+		loaded["id"] = existing["id"]
+		loaded["uid"] = existingUID
+		resolved["overwrite"] = true
+		message = fmt.Sprintf("adopted the existing dashboard %v titled %v", existingUID, title)
+	case nameConflictRename:
+		loaded["title"] = fmt.Sprintf("%v (%v)", title, cm.GetName())
+		message = fmt.Sprintf("a dashboard titled %v already exists, loaded %v as %v", title, loaded["uid"], loaded["title"])
+	case nameConflictMove:
+		err := moveDashboard(existingUID, movedDashboardsFolder)
+		if err != nil {
+			return nil, 0, err
+		}
+		message = fmt.Sprintf("moved the existing dashboard %v titled %v into the folder %v", existingUID, title, movedDashboardsFolder)
+	}
+
+	body, respStatusCode, err := postDashboardData(resolved)
+	if err != nil {
+		return nil, 0, err
+	}
+	if respStatusCode == http.StatusOK {
+		if policy == nameConflictAdopt {
+			err = recordAdoptedDashboard(cm, key, existingUID)
+			if err != nil {
+				recordWarning(cm, "DashboardNameConflict", fmt.Sprintf("adopted dashboard %v is not recorded: %v", existingUID, err))
+			}
+		}
+		recordEvent(cm, "DashboardNameConflictResolved", message)
+	} else if respStatusCode == http.StatusPreconditionFailed {
+		return nil, 0, fmt.Errorf("failed to %v the dashboard titled %v: %s", policy, title, body)
+	}
+	return body, respStatusCode, nil
+}
+
+// moveDashboard moves the dashboard with the uid into the folder, creating the folder if needed
+func moveDashboard(uid, folderTitle string) error {
+	dashboard, _, found := getLiveDashboardWithMeta(uid)
+	if !found {
+		return fmt.Errorf("failed to get dashboard %v to move it", uid)
+	}
+	folderID := createCustomFolder(folderTitle)
+	if folderID == 0 {
+		return fmt.Errorf("failed to get folder %v to move dashboard %v", folderTitle, uid)
+	}
+
+	_, respStatusCode, err := postDashboardData(map[string]interface{}{
+		"folderId":  folderID,
+		"overwrite": true,
+		"dashboard": dashboard,
+	})
+	if err != nil {
+		return err
+	}
+	if respStatusCode != http.StatusOK {
+		return fmt.Errorf("failed to move dashboard %v into the folder %v with %v", uid, folderTitle, respStatusCode)
+	}
+	return nil
+}
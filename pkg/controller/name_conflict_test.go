@@ -0,0 +1,116 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolveNameConflict(t *testing.T) {
+	posted := []map[string]interface{}{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/search", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("folderIds") != "3" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"id": 8, "uid": "manual", "title": "cpu usage"}, {"id": 9, "uid": "other", "title": "CPU"}]`))
+	})
+	mux.HandleFunc("/api/dashboards/uid/manual", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"dashboard": {"id": 8, "uid": "manual", "title": "cpu usage"}, "meta": {}}`))
+	})
+	mux.HandleFunc("/api/folders", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[{"id": 3, "uid": "team", "title": "Team"}, {"id": 5, "uid": "moved", "title": "` + movedDashboardsFolder + `"}]`))
+	})
+	mux.HandleFunc("/api/dashboards/db", func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		data := map[string]interface{}{}
+		json.Unmarshal(b, &data)
+		posted = append(posted, data)
+		w.Write([]byte("{}"))
+	})
+	startGrafanaServer(t, mux)
+
+	testCaseList := []struct {
+		name     string
+		policy   string
+		folderID float64
+		expected []map[string]interface{}
+	}{
+		{"fail", "", 3, nil},
+		{"not found", nameConflictAdopt, 4, nil},
+		{"adopt", nameConflictAdopt, 3, []map[string]interface{}{
+			{"folderId": 3.0, "overwrite": true, "dashboard": map[string]interface{}{"id": 8.0, "uid": "manual", "title": "CPU Usage"}},
+		}},
+		{"rename", nameConflictRename, 3, []map[string]interface{}{
+			{"folderId": 3.0, "overwrite": false, "dashboard": map[string]interface{}{"id": nil, "uid": "cpu", "title": "CPU Usage (test)"}},
+		}},
+		{"move", nameConflictMove, 3, []map[string]interface{}{
+			{"folderId": 5.0, "overwrite": true, "dashboard": map[string]interface{}{"id": 8.0, "uid": "manual", "title": "cpu usage"}},
+			{"folderId": 3.0, "overwrite": false, "dashboard": map[string]interface{}{"id": nil, "uid": "cpu", "title": "CPU Usage"}},
+		}},
+	}
+
+	for _, c := range testCaseList {
+		posted = []map[string]interface{}{}
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: map[string]string{}}}
+		if c.policy != "" {
+			cm.Annotations[nameConflictKey] = c.policy
+		}
+		data := map[string]interface{}{
+			"folderId":  c.folderID,
+			"overwrite": false,
+			"dashboard": map[string]interface{}{"id": nil, "uid": "cpu", "title": "CPU Usage"},
+		}
+
+		_, status, err := resolveNameConflict(cm, "cpu.json", data)
+		if c.expected == nil {
+			if err == nil || len(posted) != 0 {
+				t.Errorf("case (%v) should fail without posting, posted %v", c.name, posted)
+			}
+			continue
+		}
+		if err != nil || status != http.StatusOK {
+			t.Errorf("case (%v) failed with %v %v", c.name, err, status)
+			continue
+		}
+		b, _ := json.Marshal(posted)
+		e, _ := json.Marshal(c.expected)
+		if string(b) != string(e) {
+			t.Errorf("case (%v) posted %s instead of %s", c.name, b, e)
+		}
+	}
+}
+
+func TestRecordAdoptedDashboard(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns", UID: "1"},
+		Data:       map[string]string{"cpu.json": `{"title": "CPU Usage", "uid": "cpu"}`},
+	}
+	configmapClient = fake.NewSimpleClientset(cm).CoreV1()
+	defer func() { configmapClient = nil }()
+
+	err := recordAdoptedDashboard(cm, "cpu.json", "manual")
+	if err != nil {
+		t.Fatalf("fail to record the adopted dashboard with %v", err)
+	}
+	latest, _ := configmapClient.ConfigMaps("ns").Get(context.TODO(), "test", metav1.GetOptions{})
+	if latest.Annotations[adoptedDashboardsKey] != `{"cpu.json":"manual"}` {
+		t.Errorf("the annotations %v are not the expected", latest.Annotations)
+	}
+
+	// the adopted dashboard is loaded with its uid
+	dashboard, err := parseDashboard(latest, "cpu.json")
+	if err != nil || dashboard["uid"] != "manual" {
+		t.Errorf("the dashboard %v should be loaded with the adopted uid, %v", dashboard, err)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+const (
+	playlistLabel = "grafana-custom-playlist"
+)
+
+// playlist is the grafana playlist whose items reference dashboards by uid or tag
+type playlist struct {
+	UID      string         `json:"uid"`
+	Name     string         `json:"name"`
+	Interval string         `json:"interval"`
+	Items    []playlistItem `json:"items"`
+}
+
+type playlistItem struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func isPlaylistConfigmap(obj interface{}) bool {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return false
+	}
+
+	return strings.ToLower(cm.ObjectMeta.Labels[playlistLabel]) == "true"
+}
+
+// getPlaylists parses the yaml or json playlists of the configmap by uid,
+// generated from the configmap and data key if the playlist does not set one
+func getPlaylists(obj interface{}) map[string]playlist {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok || cm == nil {
+		return nil
+	}
+
+	playlists := map[string]playlist{}
+	for key, value := range cm.Data {
+		p := playlist{}
+		err := yaml.Unmarshal([]byte(value), &p)
+		if err != nil {
+			klog.Errorf("Failed to unmarshall data %v: %v", key, err)
+			continue
+		}
+
+		valid := true
+		for _, item := range p.Items {
+			if item.Type != "dashboard_by_uid" && item.Type != "dashboard_by_tag" {
+				klog.Errorf("invalid item type %v of playlist %v", item.Type, key)
+				valid = false
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		if p.UID == "" {
+			p.UID = generateResourceUID(cm, strings.TrimSuffix(strings.TrimSuffix(key, ".json"), ".yaml"))
+		}
+		if p.Name == "" {
+			p.Name = key
+		}
+		if p.Interval == "" {
+			p.Interval = "5m"
+		}
+		playlists[p.UID] = p
+	}
+	return playlists
+}
+
+// updatePlaylists is used to create or update the playlists via calling grafana api
+func updatePlaylists(old, new interface{}) {
+	playlists := getPlaylists(new)
+	for uid, p := range playlists {
+		b, err := json.Marshal(p)
+		if err != nil {
+			klog.Error("failed to marshal body", "error", err)
+			continue
+		}
+
+		method := "POST"
+		grafanaURL := grafanaURI + "/api/playlists"
+		if hasPlaylist(uid) {
+			method = "PUT"
+			grafanaURL = grafanaURL + "/" + uid
+		}
+		_, respStatusCode := util.SetRequest(method, grafanaURL, bytes.NewBuffer(b), retry)
+		if respStatusCode != http.StatusOK {
+			klog.Infof("failed to create/update playlist %v: %v", uid, respStatusCode)
+			continue
+		}
+		klog.Infof("Playlist %v created/updated", uid)
+	}
+
+	// delete the playlists removed from the configmap
+	for uid := range getPlaylists(old) {
+		if _, ok := playlists[uid]; !ok {
+			deletePlaylist(uid)
+		}
+	}
+}
+
+// deletePlaylists is used to delete the playlists of the configmap via calling grafana api
+func deletePlaylists(obj interface{}) {
+	for uid := range getPlaylists(obj) {
+		deletePlaylist(uid)
+	}
+}
+
+func hasPlaylist(uid string) bool {
+	grafanaURL := grafanaURI + "/api/playlists/" + uid
+	_, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	return respStatusCode == http.StatusOK
+}
+
+func deletePlaylist(uid string) {
+	grafanaURL := grafanaURI + "/api/playlists/" + uid
+	_, respStatusCode := util.SetRequest("DELETE", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK && respStatusCode != http.StatusNotFound {
+		klog.Errorf("failed to delete playlist %v with %v", uid, respStatusCode)
+		return
+	}
+	klog.Infof("Playlist %v deleted", uid)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetPlaylists(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "playlists",
+			Namespace: "ns",
+			Labels:    map[string]string{playlistLabel: "true"},
+		},
+		Data: map[string]string{
+			"noc.yaml": `
+name: NOC wall
+items:
+  - type: dashboard_by_uid
+    value: k8s-networking
+  - type: dashboard_by_tag
+    value: noc
+`,
+			"existing.json": `{"uid": "existing", "name": "Existing", "interval": "1m", "items": []}`,
+			"invalid.yaml":  "items:\n  - type: dashboard_by_id\n    value: 1\n",
+		},
+	}
+
+	if !isPlaylistConfigmap(cm) {
+		t.Errorf("the configmap should be a playlist configmap")
+	}
+
+	playlists := getPlaylists(cm)
+	if len(playlists) != 2 {
+		t.Fatalf("the playlists %v are not the expected 2", playlists)
+	}
+	noc := playlists["playlists-noc-ns"]
+	if noc.Name != "NOC wall" || noc.Interval != "5m" || len(noc.Items) != 2 {
+		t.Errorf("the playlist %v is not the expected NOC wall", noc)
+	}
+	if playlists["existing"].Interval != "1m" {
+		t.Errorf("the playlist %v is not the expected existing", playlists["existing"])
+	}
+}
+
+func TestHasPlaylist(t *testing.T) {
+	startFakeServer(t)
+
+	if !hasPlaylist("existing") {
+		t.Errorf("the playlist existing should exist")
+	}
+	if hasPlaylist("missing") {
+		t.Errorf("the playlist missing should not exist")
+	}
+}
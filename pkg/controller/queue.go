@@ -0,0 +1,228 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+var (
+	// Workers is the number of configmaps synced to grafana in parallel
+	Workers = 4
+)
+
+// retryableError is a sync error which may succeed when the change is synced again, e.g. when
+// grafana is unavailable or the folder of the dashboards was deleted meanwhile
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// isRetryableError returns whether the change is synced again after the error, other errors
+// such as invalid dashboards or conflicts are not fixed by retrying
+func isRetryableError(err error) bool {
+	retryable := &retryableError{}
+	retryErr := &util.RetryError{}
+	return errors.As(err, &retryable) || errors.As(err, &retryErr) || errors.Is(err, util.ErrCircuitOpen)
+}
+
+// isRetryableStatus returns whether a request to grafana which failed with the status code may
+// succeed when sent again, the status code is 0 when no response was received
+func isRetryableStatus(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// configmapEvent is the pending change of a configmap, old is the configmap as it was
+// before the first change which is not synced yet
+type configmapEvent struct {
+	old     interface{}
+	new     interface{}
+	deleted bool
+}
+
+// configmapQueue syncs the changed configmaps with parallel workers, the changes of one
+// configmap are merged and synced in order by one worker at a time
+type configmapQueue struct {
+	queue    workqueue.RateLimitingInterface
+	handlers []configmapHandler
+	pending  map[string]*configmapEvent
+	// finalized are the configmaps whose finalizer was removed, their dashboards are
+	// deleted already when they are gone
+	finalized map[string]bool
+	mutex     sync.Mutex
+}
+
+func newConfigmapQueue(handlers []configmapHandler) *configmapQueue {
+	return &configmapQueue{
+		queue:     workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Second, time.Minute)),
+		handlers:  handlers,
+		pending:   map[string]*configmapEvent{},
+		finalized: map[string]bool{},
+	}
+}
+
+// add queues the change of the configmap from old to new, old is nil for new configmaps
+func (q *configmapQueue) add(old, new interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(new)
+	if err != nil {
+		klog.Errorf("failed to get the key of configmap: %v", err)
+		return
+	}
+
+	q.mutex.Lock()
+	if event, ok := q.pending[key]; ok {
+		event.new = new
+		event.deleted = false
+	} else {
+		q.pending[key] = &configmapEvent{old: old, new: new}
+	}
+	q.mutex.Unlock()
+	q.queue.Add(key)
+}
+
+// addDeleted queues the deletion of the configmap
+func (q *configmapQueue) addDeleted(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("failed to get the key of configmap: %v", err)
+		return
+	}
+
+	q.mutex.Lock()
+	if event, ok := q.pending[key]; ok {
+		event.new = obj
+		event.deleted = true
+	} else {
+		q.pending[key] = &configmapEvent{new: obj, deleted: true}
+	}
+	q.mutex.Unlock()
+	q.queue.Add(key)
+}
+
+// run syncs the queued configmaps with the workers until stopped
+func (q *configmapQueue) run(workers int, stop <-chan struct{}) {
+	defer q.queue.ShutDown()
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		go wait.Until(func() {
+			for q.processNextItem() {
+			}
+		}, 0, stop)
+	}
+	<-stop
+}
+
+func (q *configmapQueue) processNextItem() bool {
+	item, shutdown := q.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer q.queue.Done(item)
+
+	key := item.(string)
+	q.mutex.Lock()
+	event, ok := q.pending[key]
+	delete(q.pending, key)
+	q.mutex.Unlock()
+	if !ok {
+		q.queue.Forget(item)
+		return true
+	}
+
+	err := q.process(key, event)
+	if isRetryableError(err) || util.IsCircuitOpen() {
+		// e.g. grafana became unavailable, the change is synced again with backoff
+		klog.Infof("failed to sync configmap %v, requeue it", key)
+		q.requeue(key, event)
+		return true
+	}
+	if err != nil {
+		klog.Errorf("failed to sync configmap %v, it is synced again when it changes: %v", key, err)
+	}
+	q.queue.Forget(item)
+	return true
+}
+
+// requeue queues the change again with backoff, merged with the changes queued meanwhile
+func (q *configmapQueue) requeue(key string, event *configmapEvent) {
+	q.mutex.Lock()
+	if pending, ok := q.pending[key]; ok {
+		pending.old = event.old
+	} else {
+		q.pending[key] = event
+	}
+	q.mutex.Unlock()
+	q.queue.AddRateLimited(key)
+}
+
+// isFinalized returns whether the deleted configmap carried the finalizer, and forgets it
+func (q *configmapQueue) isFinalized(key string, obj interface{}) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	finalized := q.finalized[key]
+	delete(q.finalized, key)
+	return finalized || isFinalizedDashboardConfigmap(obj)
+}
+
+// process syncs the change to grafana, it returns the errors of the sync, the retryable ones
+// are retried
+func (q *configmapQueue) process(key string, event *configmapEvent) error {
+	handler := getConfigmapHandler(q.handlers, event.new)
+	if handler == nil {
+		return nil
+	}
+	name := event.new.(*corev1.ConfigMap).Name
+
+	switch {
+	case event.deleted:
+		if q.isFinalized(key, event.new) {
+			klog.Infof("detect there is a %v %v deleted, it was finalized already", handler.kind, name)
+			return nil
+		}
+		klog.Infof("detect there is a %v %v deleted", handler.kind, name)
+		handler.delete(event.new)
+	case handler.finalize != nil && isDeleting(event.new):
+		if !hasDashboardFinalizer(event.new.(*corev1.ConfigMap)) {
+			// waiting for other finalizers, or finalized already
+			return nil
+		}
+		klog.Infof("detect there is a %v %v being deleted", handler.kind, name)
+		err := handler.finalize(event.new)
+		if err != nil {
+			// retried until the finalizer times out
+			return &retryableError{err}
+		}
+		q.mutex.Lock()
+		q.finalized[key] = true
+		q.mutex.Unlock()
+		return nil
+	case event.old == nil:
+		klog.Infof("detect there is a new %v %v created", handler.kind, name)
+		return handler.update(nil, event.new)
+	default:
+		klog.Infof("detect there is a %v %v updated", handler.kind, name)
+		return handler.update(event.old, event.new)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+func TestConfigmapQueue(t *testing.T) {
+	mutex := sync.Mutex{}
+	synced := []string{}
+	record := func(action string, obj interface{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		cm := obj.(*corev1.ConfigMap)
+		synced = append(synced, action+" "+cm.Name+" "+cm.ResourceVersion)
+	}
+	queue := newConfigmapQueue([]configmapHandler{{
+		kind:      "test",
+		isDesired: func(obj interface{}) bool { return true },
+		update: func(old, new interface{}) error {
+			if old == nil {
+				record("create", new)
+				return nil
+			}
+			record("update "+old.(*corev1.ConfigMap).ResourceVersion+"->", new)
+			return nil
+		},
+		delete: func(obj interface{}) { record("delete", obj) },
+	}})
+	getConfigmap := func(name, version string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", ResourceVersion: version}}
+	}
+
+	// the changes before the workers run are merged per configmap
+	queue.add(nil, getConfigmap("a", "1"))
+	queue.add(getConfigmap("a", "1"), getConfigmap("a", "2"))
+	queue.add(getConfigmap("b", "1"), getConfigmap("b", "2"))
+	queue.add(getConfigmap("b", "2"), getConfigmap("b", "3"))
+	queue.add(nil, getConfigmap("c", "1"))
+	queue.addDeleted(getConfigmap("c", "1"))
+
+	stop := make(chan struct{})
+	go queue.run(2, stop)
+	defer close(stop)
+
+	expected := map[string]bool{
+		"create a 2":     true,
+		"update 1-> b 3": true,
+		"delete c 1":     true,
+	}
+	for i := 0; i < 50; i++ {
+		mutex.Lock()
+		done := len(synced) == len(expected)
+		mutex.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	actual := map[string]bool{}
+	for _, s := range synced {
+		actual[s] = true
+	}
+	if len(synced) != len(expected) || !reflect.DeepEqual(actual, expected) {
+		t.Errorf("the synced changes %v are not the expected %v", synced, expected)
+	}
+}
+
+func TestConfigmapQueueRequeue(t *testing.T) {
+	queue := newConfigmapQueue(nil)
+	defer queue.queue.ShutDown()
+	getConfigmap := func(version string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "ns", ResourceVersion: version}}
+	}
+
+	// the requeued change is kept when nothing changed meanwhile
+	event := &configmapEvent{old: getConfigmap("1"), new: getConfigmap("2")}
+	queue.requeue("ns/a", event)
+	if queue.pending["ns/a"] != event {
+		t.Errorf("the requeued change %+v is not the expected", queue.pending["ns/a"])
+	}
+
+	// the requeued change is merged with the change queued meanwhile
+	delete(queue.pending, "ns/a")
+	queue.add(getConfigmap("2"), getConfigmap("3"))
+	queue.requeue("ns/a", event)
+	merged := queue.pending["ns/a"]
+	if merged.old.(*corev1.ConfigMap).ResourceVersion != "1" || merged.new.(*corev1.ConfigMap).ResourceVersion != "3" {
+		t.Errorf("the merged change %+v is not the expected", merged)
+	}
+}
+
+func TestConfigmapQueueFinalize(t *testing.T) {
+	finalized := []string{}
+	queue := newConfigmapQueue([]configmapHandler{{
+		kind:      "test",
+		isDesired: func(obj interface{}) bool { return true },
+		update: func(old, new interface{}) error {
+			t.Errorf("the configmap being deleted should not be updated")
+			return nil
+		},
+		delete: func(obj interface{}) { t.Errorf("the finalized configmap should not be deleted again") },
+		finalize: func(obj interface{}) error {
+			finalized = append(finalized, obj.(*corev1.ConfigMap).Name)
+			if len(finalized) == 1 {
+				return errors.New("grafana is unavailable")
+			}
+			return nil
+		},
+	}})
+	defer queue.queue.ShutDown()
+
+	now := metav1.Now()
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "ns", DeletionTimestamp: &now, Finalizers: []string{dashboardFinalizer}}}
+	queue.add(nil, cm)
+
+	// the failed finalization is requeued
+	queue.processNextItem()
+	if _, ok := queue.pending["ns/a"]; !ok || queue.queue.NumRequeues("ns/a") != 1 {
+		t.Fatalf("the failed finalization should be requeued")
+	}
+	queue.processNextItem()
+	if len(finalized) != 2 || queue.queue.NumRequeues("ns/a") != 0 {
+		t.Errorf("the configmap should be finalized again, finalized %v", finalized)
+	}
+
+	// the configmap without the finalizer is gone, its dashboards are not deleted again
+	gone := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "ns", DeletionTimestamp: &now}}
+	queue.add(cm, gone)
+	queue.processNextItem()
+	queue.addDeleted(gone)
+	queue.processNextItem()
+	if len(finalized) != 2 {
+		t.Errorf("the configmap should not be finalized again, finalized %v", finalized)
+	}
+}
+
+func TestConfigmapQueueUpdateError(t *testing.T) {
+	updated := 0
+	queue := newConfigmapQueue([]configmapHandler{{
+		kind:      "test",
+		isDesired: func(obj interface{}) bool { return true },
+		update: func(old, new interface{}) error {
+			updated++
+			switch updated {
+			case 1:
+				return &retryableError{errors.New("grafana returned 500")}
+			case 2:
+				return errors.New("invalid dashboard")
+			}
+			return nil
+		},
+		delete: func(obj interface{}) {},
+	}})
+	defer queue.queue.ShutDown()
+
+	queue.add(nil, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "ns"}})
+
+	// the failed update is requeued
+	queue.processNextItem()
+	if _, ok := queue.pending["ns/a"]; !ok || queue.queue.NumRequeues("ns/a") != 1 {
+		t.Fatalf("the failed update should be requeued")
+	}
+	// the update failing for good is not requeued
+	queue.processNextItem()
+	if _, ok := queue.pending["ns/a"]; ok || updated != 2 || queue.queue.NumRequeues("ns/a") != 0 {
+		t.Errorf("the configmap should be updated again without being requeued, updated %v times", updated)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	testCaseList := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"no error", nil, false},
+		{"invalid dashboard", errors.New("invalid dashboard"), false},
+		{"retryable", &retryableError{errors.New("folder not found")}, true},
+		{"retries exhausted", fmt.Errorf("failed: %w", &util.RetryError{Attempts: 3}), true},
+		{"circuit open", util.ErrCircuitOpen, true},
+	}
+
+	for _, c := range testCaseList {
+		output := isRetryableError(c.err)
+		if output != c.expected {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/util"
+)
+
+// SyncOptions selects the configmaps to load into grafana once
+type SyncOptions struct {
+	GrafanaURL string
+	// Files are manifest files or directories, used when Namespace is empty
+	Files      []string
+	Namespace  string
+	Kubeconfig string
+	// Prune deletes the dashboards of the synced folders which no configmap defines,
+	// and the folders left empty
+	Prune bool
+	// Workers is the number of configmaps loaded in parallel, 1 when not set
+	Workers int
+}
+
+// SyncResult is the outcome of loading the dashboards of a configmap
+type SyncResult struct {
+	ConfigMap  string `json:"configmap"`
+	Folder     string `json:"folder"`
+	Dashboards int    `json:"dashboards"`
+	Error      string `json:"error,omitempty"`
+}
+
+// PruneResult is the outcome of deleting a dashboard or folder left over in grafana
+type PruneResult struct {
+	Kind   string `json:"kind"`
+	Folder string `json:"folder"`
+	UID    string `json:"uid,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SyncReport is the summary of a sync
+type SyncReport struct {
+	ConfigMaps []SyncResult  `json:"configmaps"`
+	Pruned     []PruneResult `json:"pruned"`
+	Failures   int           `json:"failures"`
+}
+
+// SyncConfigmaps loads the dashboard configmaps into grafana the same way the controller
+// does, without watching for changes
+func SyncConfigmaps(opts SyncOptions) (*SyncReport, error) {
+	if opts.GrafanaURL != "" {
+		grafanaURI = opts.GrafanaURL
+	}
+	files, err := loadConfigmaps(opts.Files, opts.Namespace, opts.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SyncReport{ConfigMaps: []SyncResult{}, Pruned: []PruneResult{}}
+	// desired records the dashboard uids of each synced folder
+	desired := map[string]map[string]bool{}
+	// unknown records the folders with dashboards whose uids are unknown as they failed to parse
+	unknown := map[string]bool{}
+	synced := []*corev1.ConfigMap{}
+	for _, file := range files {
+		cm := file.cm
+		if !isDesiredDashboardConfigmap(cm) {
+			continue
+		}
+		result := SyncResult{
+			ConfigMap:  getConfigmapName(cm),
+			Folder:     getDashboardCustomFolderTitle(cm),
+			Dashboards: len(getDashboardKeys(cm)),
+		}
+		if desired[result.Folder] == nil {
+			desired[result.Folder] = map[string]bool{}
+		}
+		for _, key := range getDashboardKeys(cm) {
+			dashboard, err := parseDashboard(cm, key)
+			if err != nil {
+				unknown[result.Folder] = true
+				continue
+			}
+			desired[result.Folder][dashboard["uid"].(string)] = true
+		}
+
+		synced = append(synced, cm)
+		report.ConfigMaps = append(report.ConfigMaps, result)
+	}
+
+	for i, err := range updateDashboards(synced, opts.Workers) {
+		if err != nil {
+			report.ConfigMaps[i].Error = err.Error()
+			report.Failures++
+		}
+	}
+
+	if opts.Prune {
+		for title := range unknown {
+			klog.Warningf("skip pruning folder %v, some of its dashboards failed to parse", title)
+			delete(desired, title)
+		}
+		report.Pruned = pruneFolders(desired)
+		for _, pruned := range report.Pruned {
+			if pruned.Error != "" {
+				report.Failures++
+			}
+		}
+	}
+	return report, nil
+}
+
+// updateDashboards loads the dashboards of the configmaps with the workers in parallel, and
+// returns the error of each configmap
+func updateDashboards(cms []*corev1.ConfigMap, workers int) []error {
+	if workers < 1 {
+		workers = 1
+	}
+	errs := make([]error, len(cms))
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = updateDashboard(nil, cms[i], false)
+			}
+		}()
+	}
+	for i := range cms {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return errs
+}
+
+// pruneFolders deletes the dashboards loaded by the loader which are not desired from the
+// custom folders, and the folders left empty, the general folder is never pruned
+func pruneFolders(desired map[string]map[string]bool) []PruneResult {
+	titles := []string{}
+	for title := range desired {
+		if title != "" {
+			titles = append(titles, title)
+		}
+	}
+	sort.Strings(titles)
+
+	results := []PruneResult{}
+	for _, title := range titles {
+		folderID := hasCustomFolder(title)
+		if folderID == 0 {
+			continue
+		}
+		for _, dashboard := range getFolderDashboards(folderID) {
+			uid, _ := dashboard["uid"].(string)
+			if uid == "" || desired[title][uid] {
+				continue
+			}
+			if getDashboardHashTag(dashboard) == "" {
+				klog.V(2).Infof("dashboard %v was not loaded by the loader, keep it", uid)
+				continue
+			}
+			result := PruneResult{Kind: "dashboard", Folder: title, UID: uid}
+			result.Title, _ = dashboard["title"].(string)
+			if !deleteDashboardByUID(uid) {
+				result.Error = "failed to delete dashboard"
+			}
+			results = append(results, result)
+		}
+
+		if deletable, err := deleteEmptyFolder(folderID); deletable {
+			result := PruneResult{Kind: "folder", Folder: title}
+			if err != nil {
+				result.Error = "failed to delete folder"
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// getFolderDashboards returns the search results of the dashboards in the folder
+func getFolderDashboards(folderID float64) []map[string]interface{} {
+	query := url.Values{}
+	query.Set("type", "dash-db")
+	query.Set("folderIds", fmt.Sprint(folderID))
+	grafanaURL := grafanaURI + "/api/search?" + query.Encode()
+	body, respStatusCode := util.SetRequest("GET", grafanaURL, nil, retry)
+	if respStatusCode != http.StatusOK {
+		klog.Errorf("failed to search dashboards of folder %v with %v", folderID, respStatusCode)
+		return nil
+	}
+
+	dashboards := []map[string]interface{}{}
+	err := json.Unmarshal(body, &dashboards)
+	if err != nil {
+		klog.Error(unmarshallErrMsg, "error", err)
+		return nil
+	}
+	return dashboards
+}
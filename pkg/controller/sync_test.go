@@ -0,0 +1,94 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSyncConfigmaps(t *testing.T) {
+	startFakeServer(t)
+
+	_, err := SyncConfigmaps(SyncOptions{Files: []string{"missing.yaml"}})
+	if err == nil {
+		t.Errorf("syncing a missing file should fail")
+	}
+
+	report, err := SyncConfigmaps(SyncOptions{Files: []string{"../../examples/k8s-dashboard.yaml"}})
+	if err != nil {
+		t.Fatalf("fail to sync configmaps with %v", err)
+	}
+	if len(report.ConfigMaps) != 1 || report.Failures != 0 || len(report.Pruned) != 0 {
+		t.Fatalf("the report %+v is not the expected", report)
+	}
+	if result := report.ConfigMaps[0]; result.Folder != "Custom" || result.Dashboards != 1 {
+		t.Errorf("the result %+v is not the expected", result)
+	}
+}
+
+func TestPruneFolders(t *testing.T) {
+	deleted := []string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/folders", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[{"id": 4, "uid": "team", "title": "Team"}]`))
+	})
+	mux.HandleFunc("/api/search", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("type") == "dash-db" {
+			w.Write([]byte(`[{"uid": "kept", "title": "Kept", "tags": ["loader-hash:1"]},
+				{"uid": "stale", "title": "Stale", "tags": ["team", "loader-hash:2"]},
+				{"uid": "manual", "title": "Manual", "tags": ["team"]}]`))
+			return
+		}
+		w.Write([]byte(`[{"uid": "kept", "title": "Kept"}]`))
+	})
+	mux.HandleFunc("/api/dashboards/uid/", func(w http.ResponseWriter, req *http.Request) {
+		deleted = append(deleted, req.URL.Path)
+		w.Write([]byte("{}"))
+	})
+	startGrafanaServer(t, mux)
+
+	results := pruneFolders(map[string]map[string]bool{
+		"Team": {"kept": true},
+		"":     {"general": true},
+	})
+	expected := []PruneResult{{Kind: "dashboard", Folder: "Team", UID: "stale", Title: "Stale"}}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("the pruned %+v are not the expected %+v", results, expected)
+	}
+	if !reflect.DeepEqual(deleted, []string{"/api/dashboards/uid/stale"}) {
+		t.Errorf("the deleted dashboards %v are not the expected", deleted)
+	}
+
+	// the folder is not pruned when the uids of its dashboards are unknown
+	dir, err := ioutil.TempDir("", "sync")
+	if err != nil {
+		t.Fatalf("fail to create directory with %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeTestFile(t, dir, "team.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: team
+  namespace: ns
+  annotations:
+    observability.open-cluster-management.io/dashboard-folder: Team
+  labels:
+    grafana-custom-dashboard: "true"
+data:
+  broken.json: '{"title": "Broken",'
+`)
+	deleted = []string{}
+	report, err := SyncConfigmaps(SyncOptions{Files: []string{path}, Prune: true})
+	if err != nil {
+		t.Fatalf("fail to sync configmaps with %v", err)
+	}
+	if report.Failures != 1 || len(report.Pruned) != 0 || len(deleted) != 0 {
+		t.Errorf("the report %+v deleting %v is not the expected", report, deleted)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// dashboardTemplateKey renders the dashboards of the configmap with text/template
+	dashboardTemplateKey = "observability.open-cluster-management.io/dashboard-template"
+	// dashboardTemplateValuesKey names the configmap of the template values in the same namespace
+	dashboardTemplateValuesKey = "observability.open-cluster-management.io/dashboard-template-values"
+	// dashboardTemplateDelimsKey sets the template delimiters, e.g. "[[ ]]", to keep the
+	// {{ }} of grafana legends
+	dashboardTemplateDelimsKey = "observability.open-cluster-management.io/dashboard-template-delims"
+)
+
+// TemplateEnv lists the environment variables available to dashboard templates as .Env,
+// others are kept from configmap authors as they may hold secrets
+var TemplateEnv []string
+
+// templateData is the data of dashboard templates
+type templateData struct {
+	Values map[string]string
+	Env    map[string]string
+}
+
+func isTemplateConfigmap(cm *corev1.ConfigMap) bool {
+	return strings.ToLower(cm.ObjectMeta.Annotations[dashboardTemplateKey]) == "true"
+}
+
+// getTemplateData returns the values of the referenced configmap and the allowed environment
+func getTemplateData(cm *corev1.ConfigMap) (*templateData, error) {
+	data := &templateData{Values: map[string]string{}, Env: map[string]string{}}
+	for _, name := range TemplateEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			data.Env[name] = value
+		}
+	}
+
+	name := cm.ObjectMeta.Annotations[dashboardTemplateValuesKey]
+	if name == "" {
+		return data, nil
+	}
+	values, err := lookupConfigmap(cm.GetNamespace(), name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template values: %v", err)
+	}
+	for key, value := range values.Data {
+		data.Values[key] = value
+	}
+	return data, nil
+}
+
+// renderTemplate renders the text of the configmap's data key, missing values are errors
+func renderTemplate(cm *corev1.ConfigMap, key string) (string, error) {
+	data, err := getTemplateData(cm)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl := template.New(key).Option("missingkey=error")
+	if delims := strings.Fields(cm.ObjectMeta.Annotations[dashboardTemplateDelimsKey]); len(delims) == 2 {
+		tmpl = tmpl.Delims(delims[0], delims[1])
+	}
+	tmpl, err = tmpl.Parse(cm.Data[key])
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, data)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
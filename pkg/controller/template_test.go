@@ -0,0 +1,75 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	os.Setenv("HUB_REGION", "eu-west-1")
+	os.Setenv("HUB_TOKEN", "secret")
+	TemplateEnv = []string{"HUB_REGION"}
+	defer func() {
+		os.Unsetenv("HUB_REGION")
+		os.Unsetenv("HUB_TOKEN")
+		TemplateEnv = nil
+	}()
+	values := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "hub-values", Namespace: "template-test"},
+		Data:       map[string]string{"hubName": "hub-1"},
+	}
+	addConfigmapLookup(func(namespace, name string) *corev1.ConfigMap {
+		if namespace == values.GetNamespace() && name == values.GetName() {
+			return values
+		}
+		return nil
+	})
+
+	testCaseList := []struct {
+		name     string
+		values   string
+		delims   string
+		data     string
+		expected string
+		err      string
+	}{
+		{"values and env", "hub-values", "", `{"title": "{{ .Values.hubName }} {{ .Env.HUB_REGION }}"}`, "hub-1 eu-west-1", ""},
+		{"delimiters", "hub-values", "[[ ]]", `{"title": "[[ .Values.hubName ]] {{cluster}}"}`, "hub-1 {{cluster}}", ""},
+		{"missing value", "hub-values", "", `{"title": "{{ .Values.region }}"}`, "", "map has no entry"},
+		{"env not allowed", "hub-values", "", `{"title": "{{ .Env.HUB_TOKEN }}"}`, "", "map has no entry"},
+		{"missing values configmap", "missing", "", `{"title": "x"}`, "", "not found"},
+		{"invalid template", "", "", `{"title": "{{ .Values.hubName"}`, "", "failed to render template"},
+	}
+
+	for _, c := range testCaseList {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dashboards",
+				Namespace: "template-test",
+				Annotations: map[string]string{
+					dashboardTemplateKey:       "true",
+					dashboardTemplateValuesKey: c.values,
+					dashboardTemplateDelimsKey: c.delims,
+				},
+			},
+			Data: map[string]string{"hub.json": c.data},
+		}
+		dashboard, err := parseDashboard(cm, "hub.json")
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("case (%v) error: (%v) does not contain the expected: (%v)", c.name, err, c.err)
+			}
+			continue
+		}
+		if err != nil || dashboard["title"] != c.expected {
+			t.Errorf("case (%v) output: (%v, %v) is not the expected: (%v)", c.name, dashboard, err, c.expected)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// maxUIDLength is the maximum length of grafana uids
+	maxUIDLength = 40
+	// SeverityError marks issues which prevent the dashboard from loading
+	SeverityError = "error"
+	// SeverityWarning marks issues which do not prevent the dashboard from loading
+	SeverityWarning = "warning"
+)
+
+// ValidationIssue is a problem found in a dashboard configmap
+type ValidationIssue struct {
+	File      string `json:"file"`
+	ConfigMap string `json:"configmap"`
+	Key       string `json:"key,omitempty"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+}
+
+// ValidationReport is the result of validating dashboard configmaps
+type ValidationReport struct {
+	ConfigMaps int               `json:"configmaps"`
+	Dashboards int               `json:"dashboards"`
+	Errors     int               `json:"errors"`
+	Warnings   int               `json:"warnings"`
+	Issues     []ValidationIssue `json:"issues"`
+}
+
+// ValidateConfigmapFiles validates the dashboard configmaps in the manifest files and
+// directories the same way the loader would load them
+func ValidateConfigmapFiles(paths []string) (*ValidationReport, error) {
+	files, err := readConfigmapFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	addFileConfigmapLookup(files)
+
+	report := &ValidationReport{Issues: []ValidationIssue{}}
+	handlers := getConfigmapHandlers(nil)
+	// uids records where each dashboard uid is defined first
+	uids := map[string]string{}
+	for _, file := range files {
+		cm := file.cm
+		name := getConfigmapName(cm)
+		add := func(key, severity, message string) {
+			report.Issues = append(report.Issues, ValidationIssue{
+				File:      file.path,
+				ConfigMap: name,
+				Key:       key,
+				Severity:  severity,
+				Message:   message,
+			})
+		}
+
+		if !isDesiredDashboardConfigmap(cm) {
+			if getConfigmapHandler(handlers, cm) == nil {
+				add("", SeverityWarning, "not loaded, the label grafana-custom-dashboard is not set to true")
+			}
+			continue
+		}
+		report.ConfigMaps++
+		if policy, ok := cm.Annotations[conflictPolicyKey]; ok && !isValidConflictPolicy(policy) {
+			add("", SeverityWarning, CheckConflictPolicy(policy).Error()+", the default is used")
+		}
+		if policy, ok := cm.Annotations[nameConflictKey]; ok && !isValidNameConflictPolicy(policy) {
+			add("", SeverityWarning, fmt.Sprintf("invalid name conflict policy %v, one of fail, adopt, rename or move is expected, fail is used", policy))
+		}
+		if len(getDashboardKeys(cm)) == 0 {
+			add("", SeverityWarning, "no dashboards in data")
+		}
+
+		for _, key := range getDashboardKeys(cm) {
+			report.Dashboards++
+			dashboard, err := parseDashboard(cm, key)
+			if err != nil {
+				add(key, SeverityError, describeJSONError(err))
+				continue
+			}
+
+			for _, message := range validateDashboard(dashboard) {
+				add(key, SeverityError, message)
+			}
+
+			uid := dashboard["uid"].(string)
+			location := name + " " + key
+			if first, ok := uids[uid]; ok {
+				add(key, SeverityError, fmt.Sprintf("duplicate uid %v, also used by %v", uid, first))
+			} else {
+				uids[uid] = location
+			}
+		}
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Severity == SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	return report, nil
+}
+
+// describeJSONError adds the line and column of syntax errors in the rendered dashboard
+func describeJSONError(err error) string {
+	jsonErr, ok := err.(*dashboardJSONError)
+	if !ok {
+		return "invalid dashboard: " + err.Error()
+	}
+	syntaxErr, ok := jsonErr.err.(*json.SyntaxError)
+	if !ok {
+		return "invalid dashboard: " + err.Error()
+	}
+
+	// the offset is after the invalid character
+	offset := int(syntaxErr.Offset) - 1
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(jsonErr.data) {
+		offset = len(jsonErr.data)
+	}
+	before := jsonErr.data[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := len(before) - strings.LastIndex(before, "\n")
+	return fmt.Sprintf("invalid JSON at line %v column %v: %v", line, column, err)
+}
+
+// validateDashboard checks the dashboard model for the problems grafana rejects or mishandles
+func validateDashboard(dashboard map[string]interface{}) []string {
+	messages := []string{}
+
+	title, ok := dashboard["title"].(string)
+	if !ok || strings.TrimSpace(title) == "" {
+		messages = append(messages, "missing title")
+	}
+
+	uid, _ := dashboard["uid"].(string)
+	if len(uid) > maxUIDLength {
+		messages = append(messages, fmt.Sprintf("uid %v is longer than %v characters", uid, maxUIDLength))
+	} else if invalidUIDChars.MatchString(uid) {
+		messages = append(messages, fmt.Sprintf("uid %v contains characters other than letters, digits, - and _", uid))
+	}
+
+	if panels, ok := dashboard["panels"]; ok && panels != nil {
+		list, ok := panels.([]interface{})
+		if !ok {
+			messages = append(messages, "panels must be an array")
+		}
+		for i, panel := range list {
+			if _, ok := panel.(map[string]interface{}); !ok {
+				messages = append(messages, fmt.Sprintf("panel %v must be an object", i))
+			}
+		}
+	}
+
+	if tags, ok := dashboard["tags"]; ok && tags != nil {
+		list, ok := tags.([]interface{})
+		if !ok {
+			messages = append(messages, "tags must be an array")
+		}
+		for _, tag := range list {
+			if _, ok := tag.(string); !ok {
+				messages = append(messages, fmt.Sprintf("tag %v must be a string", tag))
+			}
+		}
+	}
+
+	if templating, ok := dashboard["templating"]; ok && templating != nil {
+		t, ok := templating.(map[string]interface{})
+		if !ok {
+			messages = append(messages, "templating must be an object")
+		} else if list, ok := t["list"]; ok && list != nil {
+			if _, ok := list.([]interface{}); !ok {
+				messages = append(messages, "templating.list must be an array")
+			}
+		}
+	}
+
+	if version, ok := dashboard["schemaVersion"]; ok && version != nil {
+		if _, ok := version.(float64); !ok {
+			messages = append(messages, "schemaVersion must be a number")
+		}
+	}
+
+	return messages
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package controller
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateConfigmapFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate")
+	if err != nil {
+		t.Fatalf("fail to create directory with %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := writeTestFile(t, dir, "dashboards.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: broken
+  namespace: ns
+  annotations:
+    observability.open-cluster-management.io/dashboard-conflict-policy: always
+    observability.open-cluster-management.io/dashboard-name-conflict: replace
+  labels:
+    grafana-custom-dashboard: "true"
+data:
+  a.json: |-
+    {
+      "title": "A",
+      "uid": "ff635a025bcfea7bc3dd4f508990a3e8"
+    }
+  b.json: |-
+    {
+      "title": "B",
+      "panels": {}
+    }
+  c.json: |-
+    {
+      "title": "C",
+    }
+  d.json: |-
+    {
+      "uid": "a-very-long-uid-which-grafana-will-reject-for-sure"
+    }
+  e.json: |-
+    {
+      "title": "E"
+    }
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unlabelled
+  namespace: ns
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: panels
+  namespace: ns
+  labels:
+    grafana-custom-library-panel: "true"
+`)
+
+	report, err := ValidateConfigmapFiles([]string{path, "../../examples/k8s-dashboard.yaml"})
+	if err != nil {
+		t.Fatalf("fail to validate configmaps with %v", err)
+	}
+
+	expected := []ValidationIssue{
+		{path, "ns/broken", "", SeverityWarning, "invalid conflict policy always, one of overwrite, loader-owned or refuse is expected, the default is used"},
+		{path, "ns/broken", "", SeverityWarning, "invalid name conflict policy replace, one of fail, adopt, rename or move is expected, fail is used"},
+		{path, "ns/broken", "b.json", SeverityError, "panels must be an array"},
+		{path, "ns/broken", "c.json", SeverityError, "invalid JSON at line 3 column 1: invalid character '}' looking for beginning of object key string"},
+		{path, "ns/broken", "d.json", SeverityError, "missing title"},
+		{path, "ns/broken", "d.json", SeverityError, "uid a-very-long-uid-which-grafana-will-reject-for-sure is longer than 40 characters"},
+		{path, "ns/broken", "e.json", SeverityError, "duplicate uid broken-ns, also used by ns/broken b.json"},
+		{path, "ns/unlabelled", "", SeverityWarning, "not loaded, the label grafana-custom-dashboard is not set to true"},
+		{"../../examples/k8s-dashboard.yaml", "/networking-cluster", "k8s-networking-cluster.json", SeverityError,
+			"duplicate uid ff635a025bcfea7bc3dd4f508990a3e8, also used by ns/broken a.json"},
+	}
+	if len(report.Issues) != len(expected) {
+		t.Fatalf("the issues %v are not the expected %v", report.Issues, expected)
+	}
+	for i := range expected {
+		if report.Issues[i] != expected[i] {
+			t.Errorf("the issue %v is not the expected %v", report.Issues[i], expected[i])
+		}
+	}
+	if report.ConfigMaps != 2 || report.Dashboards != 6 || report.Errors != 6 || report.Warnings != 3 {
+		t.Errorf("the report %+v does not have the expected counts", report)
+	}
+}
+
+func TestDescribeJSONError(t *testing.T) {
+	testCaseList := []struct {
+		name      string
+		templated bool
+		data      string
+		expected  string
+	}{
+		{"invalid json", false, "{\n  \"title\": \"A\",\n}", "invalid JSON at line 3 column 1: invalid character '}' looking for beginning of object key string"},
+		{"rendered longer than the template", true, `{{ printf "%80s" "{" }},}`, "invalid JSON at line 1 column 81: invalid character ',' looking for beginning of object key string"},
+		{"invalid template", true, `{{ .Values.hubName`, "invalid dashboard: failed to render template"},
+	}
+
+	for _, c := range testCaseList {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"},
+			Data:       map[string]string{"a.json": c.data},
+		}
+		if c.templated {
+			cm.Annotations = map[string]string{dashboardTemplateKey: "true"}
+		}
+		_, err := parseDashboard(cm, "a.json")
+		if err == nil {
+			t.Fatalf("case (%v) the dashboard should be invalid", c.name)
+		}
+		output := describeJSONError(err)
+		if !strings.HasPrefix(output, c.expected) {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+}
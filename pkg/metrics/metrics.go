@@ -0,0 +1,99 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/klog"
+)
+
+const (
+	namespace = "grafana_dashboard_loader"
+)
+
+var (
+	// DryRunRequests counts the requests to grafana skipped in dry run mode
+	DryRunRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "dry_run_requests_total",
+			Help:      "Requests changing grafana which were logged instead of sent in dry run mode.",
+		},
+		[]string{"method", "resource"},
+	)
+
+	// RequestRetries counts the requests to grafana which were sent again after a failure
+	RequestRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "request_retries_total",
+			Help:      "Requests to grafana retried after a transport error, 429 or 5xx response.",
+		},
+		[]string{"method", "resource"},
+	)
+
+	// CircuitBreakerState is the state of the circuit breaker around grafana
+	CircuitBreakerState = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "circuit_breaker_state",
+			Help:      "State of the circuit breaker around grafana, 0 closed, 1 half-open, 2 open.",
+		},
+	)
+
+	// CircuitBreakerOpened counts the times the circuit breaker opened
+	CircuitBreakerOpened = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "circuit_breaker_opened_total",
+			Help:      "Times grafana failed repeatedly and the requests to it were stopped.",
+		},
+	)
+
+	// ReadinessCheck reports whether the loader is ready, served at /readyz
+	ReadinessCheck = func() error { return nil }
+
+	registry = prometheus.NewRegistry()
+)
+
+func init() {
+	registry.MustRegister(
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		prometheus.NewGoCollector(),
+		DryRunRequests,
+		RequestRetries,
+		CircuitBreakerState,
+		CircuitBreakerOpened,
+	)
+}
+
+// Handler returns the handler serving the metrics in the prometheus format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// readyHandler serves 200 when the loader is ready, and 503 with the reason otherwise
+func readyHandler(w http.ResponseWriter, req *http.Request) {
+	err := ReadinessCheck()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok"))
+}
+
+// Serve serves the metrics at /metrics and the readiness at /readyz on the address
+func Serve(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	mux.HandleFunc("/readyz", readyHandler)
+	klog.Infof("serving metrics at %v", addr)
+	err := http.ListenAndServe(addr, mux)
+	if err != nil {
+		klog.Error("Failed to serve metrics", "error", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package metrics
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	DryRunRequests.WithLabelValues("POST", "dashboards").Inc()
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+	expected := `grafana_dashboard_loader_dry_run_requests_total{method="POST",resource="dashboards"} 1`
+	if !strings.Contains(string(body), expected) {
+		t.Errorf("the metrics %v do not contain %v", string(body), expected)
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	defer func() { ReadinessCheck = func() error { return nil } }()
+
+	recorder := httptest.NewRecorder()
+	readyHandler(recorder, httptest.NewRequest("GET", "/readyz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("the status %v is not the expected", recorder.Code)
+	}
+
+	ReadinessCheck = func() error { return errors.New("unavailable") }
+	recorder = httptest.NewRecorder()
+	readyHandler(recorder, httptest.NewRequest("GET", "/readyz", nil))
+	if recorder.Code != http.StatusServiceUnavailable || !strings.Contains(recorder.Body.String(), "unavailable") {
+		t.Errorf("the status %v with %v is not the expected", recorder.Code, recorder.Body.String())
+	}
+}
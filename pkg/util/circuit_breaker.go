@@ -0,0 +1,151 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package util
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/metrics"
+)
+
+// circuitState is the state of the circuit breaker, as exposed by the metric
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitHalfOpen
+	circuitOpen
+)
+
+var (
+	// CircuitFailureThreshold is the number of failed requests in a row opening the circuit
+	CircuitFailureThreshold = 5
+	// CircuitOpenDuration is the time the circuit stays open before grafana is probed again
+	CircuitOpenDuration = 30 * time.Second
+
+	// ErrCircuitOpen is returned for the requests not sent while grafana is unavailable
+	ErrCircuitOpen = errors.New("grafana is unavailable, the circuit breaker is open")
+
+	breaker = newCircuitBreaker()
+)
+
+// circuitBreaker stops sending requests to grafana after repeated failures, once the circuit
+// has been open for CircuitOpenDuration the health of grafana is probed to close it again
+type circuitBreaker struct {
+	state    circuitState
+	failures int
+	openedAt time.Time
+	mutex    sync.Mutex
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	metrics.CircuitBreakerState.Set(float64(circuitClosed))
+	return &circuitBreaker{}
+}
+
+func (b *circuitBreaker) setState(state circuitState) {
+	b.state = state
+	metrics.CircuitBreakerState.Set(float64(state))
+}
+
+// allow returns whether a request to the url may be sent, grafana is probed when the circuit
+// has been open long enough, requests fail fast while the probe is running
+func (b *circuitBreaker) allow(rawURL string) bool {
+	b.mutex.Lock()
+	switch {
+	case b.state == circuitClosed:
+		b.mutex.Unlock()
+		return true
+	case b.state == circuitHalfOpen || time.Since(b.openedAt) < CircuitOpenDuration:
+		b.mutex.Unlock()
+		return false
+	}
+	b.setState(circuitHalfOpen)
+	b.mutex.Unlock()
+
+	healthy := probeHealth(rawURL)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !healthy {
+		klog.Warningf("grafana is still unavailable, retry in %v", CircuitOpenDuration)
+		b.openedAt = time.Now()
+		b.setState(circuitOpen)
+		return false
+	}
+	klog.Info("grafana is available again, the circuit breaker is closed")
+	b.failures = 0
+	b.setState(circuitClosed)
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.failures = 0
+}
+
+func (b *circuitBreaker) failure() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.failures++
+	if b.state == circuitClosed && b.failures >= CircuitFailureThreshold {
+		klog.Errorf("grafana failed %v requests in a row, stop sending requests for %v", b.failures, CircuitOpenDuration)
+		metrics.CircuitBreakerOpened.Inc()
+		b.openedAt = time.Now()
+		b.setState(circuitOpen)
+	}
+}
+
+func (b *circuitBreaker) isOpen() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state != circuitClosed
+}
+
+// probeHealth returns whether the grafana of the url reports to be healthy, the probe times out
+// as the requests do so that a hanging grafana does not keep the circuit half open
+func probeHealth(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	ctx := context.Background()
+	if RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, RequestTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.Scheme+"://"+u.Host+"/api/health", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := doRequest(req)
+	if err != nil {
+		klog.V(2).Infof("failed to probe the health of grafana: %v", err)
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// IsCircuitOpen returns whether requests to grafana fail fast as it is unavailable
+func IsCircuitOpen() bool {
+	return breaker.isOpen()
+}
+
+// CheckCircuit returns ErrCircuitOpen while requests to grafana fail fast, for readiness checks
+func CheckCircuit() error {
+	if breaker.isOpen() {
+		return ErrCircuitOpen
+	}
+	return nil
+}
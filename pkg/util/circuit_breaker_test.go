@@ -0,0 +1,93 @@
+// Copyright (c) 2026 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	sleep = func(time.Duration) {}
+	previousThreshold, previousDuration := CircuitFailureThreshold, CircuitOpenDuration
+	CircuitFailureThreshold = 2
+	CircuitOpenDuration = 100 * time.Millisecond
+	breaker = newCircuitBreaker()
+	defer func() {
+		sleep = time.Sleep
+		CircuitFailureThreshold, CircuitOpenDuration = previousThreshold, previousDuration
+		breaker = newCircuitBreaker()
+	}()
+
+	healthy := false
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if req.URL.Path != "/api/health" {
+			requests++
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	// the circuit opens once the failures reach the threshold, the request fails fast then
+	_, status, err := SendRequest("GET", server.URL+"/api/folders", nil, 10)
+	if err != ErrCircuitOpen || status != 0 || !IsCircuitOpen() || CheckCircuit() == nil {
+		t.Fatalf("the circuit should be open: %v %v", err, status)
+	}
+
+	// grafana is not probed before the circuit has been open long enough
+	healthy = true
+	_, _, err = SendRequest("GET", server.URL+"/api/folders", nil, 10)
+	if err != ErrCircuitOpen || requests != 0 {
+		t.Errorf("the request should fail fast: %v after %v requests", err, requests)
+	}
+
+	// the probe fails while grafana is unavailable, and the circuit opens again
+	healthy = false
+	time.Sleep(CircuitOpenDuration)
+	_, _, err = SendRequest("GET", server.URL+"/api/folders", nil, 10)
+	if err != ErrCircuitOpen || !IsCircuitOpen() {
+		t.Errorf("the circuit should open again: %v", err)
+	}
+
+	// the successful probe closes the circuit
+	healthy = true
+	time.Sleep(CircuitOpenDuration)
+	_, status, err = SendRequest("GET", server.URL+"/api/folders", nil, 10)
+	if err != nil || status != http.StatusOK || requests != 1 || IsCircuitOpen() || CheckCircuit() != nil {
+		t.Errorf("the circuit should be closed: %v %v after %v requests", err, status, requests)
+	}
+}
+
+func TestCircuitBreakerProbeTimeout(t *testing.T) {
+	previousDuration, previousTimeout := CircuitOpenDuration, RequestTimeout
+	CircuitOpenDuration = 0
+	RequestTimeout = 100 * time.Millisecond
+	breaker = newCircuitBreaker()
+	defer func() {
+		CircuitOpenDuration, RequestTimeout = previousDuration, previousTimeout
+		breaker = newCircuitBreaker()
+	}()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// hang until the probe times out
+		<-req.Context().Done()
+	}))
+	defer server.Close()
+
+	breaker.openedAt = time.Now()
+	breaker.setState(circuitOpen)
+	if breaker.allow(server.URL) {
+		t.Fatalf("the request should not be allowed when the probe times out")
+	}
+	if breaker.state != circuitOpen {
+		t.Errorf("the circuit should open again instead of %v", breaker.state)
+	}
+}
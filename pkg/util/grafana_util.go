@@ -4,20 +4,100 @@
 package util
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"hash/fnv"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"golang.org/x/time/rate"
 	"k8s.io/klog"
+
+	"github.com/stolostron/grafana-dashboard-loader/pkg/metrics"
 )
 
 const (
 	defaultAdmin = "WHAT_YOU_ARE_DOING_IS_VOIDING_SUPPORT_0000000000000000000000000000000000000000000000000000000000000000"
 )
 
+var (
+	// DryRun logs the requests changing grafana instead of sending them
+	DryRun = false
+
+	// httpClient is shared by all requests to keep the connections to grafana alive
+	httpClient = &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	// limiter limits the requests per second to grafana, unlimited by default
+	limiter = rate.NewLimiter(rate.Inf, 1)
+	// RequestTimeout is the timeout of each attempt of a request, no timeout if not positive
+	RequestTimeout = 30 * time.Second
+
+	// RetryBaseDelay is the delay before the first retry, doubled for each further retry
+	RetryBaseDelay = 500 * time.Millisecond
+	// RetryMaxDelay is the maximum delay between retries
+	RetryMaxDelay = 30 * time.Second
+	// MaxRetryElapsed is the maximum time spent retrying a request
+	MaxRetryElapsed = 2 * time.Minute
+	sleep           = time.Sleep
+)
+
+// RetryError is returned when a request still fails after all retries
+type RetryError struct {
+	Method     string
+	URL        string
+	Attempts   int
+	StatusCode int
+	Err        error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("failed to %v %v after %v attempts: %v", e.Method, e.URL, e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// RejectedError is returned when grafana rejects a request with a client error
+type RejectedError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("grafana rejected %v %v with %v: %s", e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// SetRateLimit limits the requests to grafana to qps per second with bursts of burst
+// requests, qps 0 removes the limit
+func SetRateLimit(qps float64, burst int) {
+	if qps <= 0 {
+		limiter = rate.NewLimiter(rate.Inf, 1)
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	limiter = rate.NewLimiter(rate.Limit(qps), burst)
+}
+
 // GenerateUID generates UID for customized dashboard
 func GenerateUID(namespace string, name string) (string, error) {
 	uid := namespace + "-" + name
@@ -32,43 +112,196 @@ func GenerateUID(namespace string, name string) (string, error) {
 	return uid, nil
 }
 
+// GetGrafanaUser returns the user the requests to grafana are sent as
+func GetGrafanaUser() string {
+	return defaultAdmin
+}
+
 // GetHTTPClient returns http client
 func getHTTPClient() *http.Client {
-	transport := &http.Transport{}
-	client := &http.Client{Transport: transport}
-	return client
+	return httpClient
 }
 
-// SetRequest ...
+// doRequest sends the request once the rate limit allows it
+func doRequest(req *http.Request) (*http.Response, error) {
+	err := limiter.Wait(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return getHTTPClient().Do(req)
+}
+
+// getResource returns the grafana api resource of the url, e.g. dashboards for /api/dashboards/db
+func getResource(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(strings.TrimPrefix(u.Path, "/api/"), "/")
+	if parts[0] == "v1" && len(parts) > 2 {
+		// e.g. /api/v1/provisioning/alert-rules
+		return parts[2]
+	}
+	return parts[0]
+}
+
+// dryRunRequest logs the request changing grafana and reports success
+func dryRunRequest(method string, url string, body io.Reader) ([]byte, int) {
+	size := 0
+	if body != nil {
+		b, _ := ioutil.ReadAll(body)
+		size = len(b)
+		klog.V(2).Infof("dry-run: request body %s", b)
+	}
+	klog.Infof("dry-run: would %v %v with %v bytes", method, url, size)
+	metrics.DryRunRequests.WithLabelValues(method, getResource(url)).Inc()
+	return []byte("{}"), http.StatusOK
+}
+
+// SetRequest sends the request to grafana with retries, and returns the response body and
+// status code, the status code is 0 when no response is received
 func SetRequest(method string, url string, body io.Reader, retry int) ([]byte, int) {
-	req, _ := http.NewRequest(method, url, body)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Forwarded-User", defaultAdmin)
+	return SetRequestAsUser(defaultAdmin, method, url, body, retry)
+}
+
+// SetRequestAsUser sends the request as SetRequest does on behalf of the grafana user, e.g. to
+// change the preferences of the user
+func SetRequestAsUser(user, method string, url string, body io.Reader, retry int) ([]byte, int) {
+	respBody, respStatusCode, err := SendRequestAsUser(user, method, url, body, retry)
+	// the callers handle the rejections by the status code
+	retryErr := &RetryError{}
+	if errors.As(err, &retryErr) {
+		klog.Error(err)
+	}
+	return respBody, respStatusCode
+}
+
+// SendRequest sends the request to grafana up to retry times, transport errors, 429 and 5xx
+// responses are retried with exponential backoff, it returns a *RetryError when the retries
+// are exhausted, a *RejectedError when grafana rejects the request and ErrCircuitOpen when
+// grafana is unavailable
+func SendRequest(method string, url string, body io.Reader, retry int) ([]byte, int, error) {
+	return SendRequestAsUser(defaultAdmin, method, url, body, retry)
+}
+
+// SendRequestAsUser sends the request as SendRequest does, authenticated as the grafana user
+// by the auth proxy header
+func SendRequestAsUser(user, method string, url string, body io.Reader, retry int) ([]byte, int, error) {
+	if DryRun && method != http.MethodGet {
+		respBody, respStatusCode := dryRunRequest(method, url, body)
+		return respBody, respStatusCode, nil
+	}
+
+	// the body is read once and sent again on each attempt
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = ioutil.ReadAll(body)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read the body of %v %v: %v", method, url, err)
+		}
+	}
 
-	resp, err := getHTTPClient().Do(req)
-	times := 0
-	for {
+	if retry < 1 {
+		retry = 1
+	}
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		if !breaker.allow(url) {
+			return nil, 0, ErrCircuitOpen
+		}
+		respBody, respStatusCode, retryAfter, err := sendOnce(user, method, url, payload)
+		if err != nil || respStatusCode >= http.StatusInternalServerError {
+			breaker.failure()
+		} else {
+			breaker.success()
+		}
+		if err == nil && !isRetryableStatus(respStatusCode) {
+			if respStatusCode >= http.StatusBadRequest {
+				return respBody, respStatusCode, &RejectedError{Method: method, URL: url, StatusCode: respStatusCode, Body: respBody}
+			}
+			return respBody, respStatusCode, nil
+		}
 		if err == nil {
-			break
+			err = fmt.Errorf("got %v %s", respStatusCode, respBody)
 		}
-		klog.Error("failed to send HTTP request. Retry in 5 seconds ", "error ", err)
-		time.Sleep(time.Second * 5)
-		times++
-		if times == retry {
-			klog.Errorf("failed to send HTTP request after retrying %v times", retry)
-			break
+
+		delay := getRetryDelay(attempt, retryAfter)
+		if attempt >= retry || time.Since(start)+delay > MaxRetryElapsed {
+			return respBody, respStatusCode, &RetryError{Method: method, URL: url, Attempts: attempt, StatusCode: respStatusCode, Err: err}
 		}
-		resp, err = getHTTPClient().Do(req)
+		klog.Warningf("failed to %v %v: %v, retry in %v", method, url, err, delay)
+		metrics.RequestRetries.WithLabelValues(method, getResource(url)).Inc()
+		sleep(delay)
 	}
+}
 
-	if resp != nil {
-		defer resp.Body.Close()
-		respBody, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			klog.Info("failed to parse response body ", "error ", err)
+// sendOnce sends the request, and returns the delay of the Retry-After header of the response
+func sendOnce(user, method string, url string, payload []byte) ([]byte, int, time.Duration, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	ctx := context.Background()
+	if RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, RequestTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-User", user)
+
+	resp, err := doRequest(req)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		klog.Info("failed to parse response body ", "error ", err)
+	}
+	return respBody, resp.StatusCode, parseRetryAfter(resp.Header.Get("Retry-After")), nil
+}
+
+// isRetryableStatus returns whether the request may succeed when sent again
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// parseRetryAfter returns the delay of the Retry-After header, in seconds or as a http date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
 		}
-		return respBody, resp.StatusCode
-	} else {
-		return nil, http.StatusNotFound
 	}
+	return 0
+}
+
+// getRetryDelay returns the delay before the next attempt, doubling from RetryBaseDelay up
+// to RetryMaxDelay with jitter, or the delay grafana asked for
+func getRetryDelay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	delay := RetryMaxDelay
+	if attempt < 32 && RetryBaseDelay<<uint(attempt-1) < RetryMaxDelay {
+		delay = RetryBaseDelay << uint(attempt-1)
+	}
+	// wait between half and the full delay, so that failed requests are not retried together
+	half := int64(delay / 2)
+	if half <= 0 {
+		return delay
+	}
+	return time.Duration(half + rand.Int63n(half+1))
 }
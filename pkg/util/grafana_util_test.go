@@ -4,9 +4,16 @@
 package util
 
 import (
+	"context"
+	"errors"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 func TestGenerateUID(t *testing.T) {
@@ -32,7 +39,7 @@ func createFakeServer(t *testing.T) {
 	)
 	err := http.ListenAndServe(":3002", server3002)
 	if err != nil {
-		t.Fatal("fail to create internal server at 3002")
+		t.Error("fail to create internal server at 3002")
 	}
 }
 
@@ -44,3 +51,187 @@ func TestSetRequest(t *testing.T) {
 		t.Fatalf("cannot send request to server: %v", responseCode)
 	}
 }
+
+func TestGetResource(t *testing.T) {
+	testCaseList := []struct {
+		url      string
+		expected string
+	}{
+		{"http://127.0.0.1:3001/api/dashboards/db", "dashboards"},
+		{"http://127.0.0.1:3001/api/folders/abc", "folders"},
+		{"http://127.0.0.1:3001/api/v1/provisioning/alert-rules/abc", "alert-rules"},
+		{"http://127.0.0.1:3001/api/search?folderIds=1", "search"},
+	}
+
+	for _, c := range testCaseList {
+		output := getResource(c.url)
+		if output != c.expected {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.url, output, c.expected)
+		}
+	}
+}
+
+func TestDryRunRequest(t *testing.T) {
+	DryRun = true
+	defer func() { DryRun = false }()
+
+	// nothing listens on the port, the request must not be sent
+	body, responseCode := SetRequest("POST", "http://127.0.0.1:3003/api/dashboards/db", strings.NewReader("{}"), 1)
+	if responseCode != http.StatusOK || string(body) != "{}" {
+		t.Fatalf("the request should be logged instead of sent: %v %s", responseCode, body)
+	}
+}
+
+func TestSetRateLimit(t *testing.T) {
+	defer SetRateLimit(0, 0)
+
+	SetRateLimit(10, 2)
+	if limiter.Limit() != 10 || limiter.Burst() != 2 {
+		t.Errorf("the limit %v with burst %v is not the expected", limiter.Limit(), limiter.Burst())
+	}
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		limiter.Wait(context.Background())
+	}
+	// the two requests after the burst wait for 100ms each
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("the requests were not limited, they took %v", elapsed)
+	}
+
+	SetRateLimit(0, 0)
+	if limiter.Limit() != rate.Inf {
+		t.Errorf("the limit %v should be removed", limiter.Limit())
+	}
+}
+
+func TestSendRequest(t *testing.T) {
+	delays := []time.Duration{}
+	sleep = func(d time.Duration) { delays = append(delays, d) }
+	breaker = newCircuitBreaker()
+	defer func() {
+		sleep = time.Sleep
+		breaker = newCircuitBreaker()
+	}()
+
+	bodies := []string{}
+	statuses := []int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+		status := statuses[0]
+		statuses = statuses[1:]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "3")
+		}
+		w.WriteHeader(status)
+		w.Write([]byte("done"))
+	}))
+	defer server.Close()
+
+	// the body is sent again on each attempt
+	statuses = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}
+	body, status, err := SendRequest("POST", server.URL, strings.NewReader("{}"), 3)
+	if err != nil || status != http.StatusOK || string(body) != "done" {
+		t.Errorf("the request should succeed after retries: %v %v %s", err, status, body)
+	}
+	if strings.Join(bodies, ",") != "{},{},{}" {
+		t.Errorf("the bodies %v are not sent on each attempt", bodies)
+	}
+	if len(delays) != 2 || delays[1] != 3*time.Second {
+		t.Errorf("the delays %v do not follow the Retry-After header", delays)
+	}
+
+	// client errors are not retried
+	bodies = []string{}
+	statuses = []int{http.StatusBadRequest}
+	_, status, err = SendRequest("POST", server.URL, strings.NewReader("{}"), 3)
+	rejected := &RejectedError{}
+	if !errors.As(err, &rejected) || status != http.StatusBadRequest || len(bodies) != 1 {
+		t.Errorf("the rejection %v with %v after %v attempts is not the expected", err, status, len(bodies))
+	}
+
+	// the last response is returned when the retries are exhausted
+	statuses = []int{http.StatusBadGateway, http.StatusBadGateway}
+	_, status, err = SendRequest("GET", server.URL, nil, 2)
+	retryErr := &RetryError{}
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 2 || status != http.StatusBadGateway {
+		t.Errorf("the error %v with %v is not the expected", err, status)
+	}
+
+	// no status is reported without a response
+	_, status, err = SendRequest("GET", "http://127.0.0.1:3003", nil, 2)
+	if !errors.As(err, &retryErr) || status != 0 {
+		t.Errorf("the error %v with %v is not the expected", err, status)
+	}
+
+	breaker = newCircuitBreaker()
+	_, _, err = SendRequest("GET", "://invalid", nil, 1)
+	if err == nil || err == ErrCircuitOpen {
+		t.Errorf("an invalid url should fail")
+	}
+}
+
+func TestSendRequestTimeout(t *testing.T) {
+	sleep = func(d time.Duration) {}
+	previous := RequestTimeout
+	RequestTimeout = 100 * time.Millisecond
+	breaker = newCircuitBreaker()
+	defer func() {
+		sleep = time.Sleep
+		RequestTimeout = previous
+		breaker = newCircuitBreaker()
+	}()
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		attempts++
+		if attempts == 1 {
+			// hang until the attempt times out
+			<-req.Context().Done()
+			return
+		}
+		w.Write([]byte("done"))
+	}))
+	defer server.Close()
+
+	// the timed out attempt is retried
+	body, status, err := SendRequest("GET", server.URL, nil, 3)
+	if err != nil || status != http.StatusOK || string(body) != "done" || attempts != 2 {
+		t.Errorf("the request should succeed after the timed out attempt: %v %v %s after %v attempts", err, status, body, attempts)
+	}
+}
+
+func TestGetRetryDelay(t *testing.T) {
+	testCaseList := []struct {
+		attempt    int
+		retryAfter time.Duration
+		min        time.Duration
+		max        time.Duration
+	}{
+		{1, 0, RetryBaseDelay / 2, RetryBaseDelay},
+		{3, 0, 2 * RetryBaseDelay, 4 * RetryBaseDelay},
+		{40, 0, RetryMaxDelay / 2, RetryMaxDelay},
+		{1, 5 * time.Second, 5 * time.Second, 5 * time.Second},
+	}
+
+	for _, c := range testCaseList {
+		delay := getRetryDelay(c.attempt, c.retryAfter)
+		if delay < c.min || delay > c.max {
+			t.Errorf("case (%v, %v) output: (%v) is not between %v and %v", c.attempt, c.retryAfter, delay, c.min, c.max)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if delay := parseRetryAfter("120"); delay != 2*time.Minute {
+		t.Errorf("the delay %v is not the expected", delay)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if delay := parseRetryAfter(date); delay <= 0 || delay > time.Minute {
+		t.Errorf("the delay %v of %v is not the expected", delay, date)
+	}
+	if delay := parseRetryAfter("invalid"); delay != 0 {
+		t.Errorf("the delay %v is not the expected", delay)
+	}
+}